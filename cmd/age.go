@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kdwils/splinter/pkg/sops"
+)
+
+// loadEncrypter reads an age recipients file used to encrypt Secret values
+func loadEncrypter(recipientsFile string) (*sops.Encrypter, error) {
+	f, err := os.Open(recipientsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	e, err := sops.ReadRecipients(f, sops.SecretRegex)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", recipientsFile, err)
+	}
+	return e, nil
+}
+
+// loadDecrypter reads an age identity file used to decrypt sops encrypted documents
+func loadDecrypter(identityFile string) (*sops.Decrypter, error) {
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d, err := sops.ReadIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", identityFile, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	chartInputFiles      []string
+	chartOutputPath      string
+	chartOptions         parser.ChartOptions
+	chartSeparateCluster bool
+)
+
+// chartCmd represents the chart command
+var chartCmd = &cobra.Command{
+	Use:   "chart",
+	Short: "generate a helm chart from kubernetes manifests",
+	Long: `generate a helm chart from kubernetes manifests. CustomResourceDefinitions are written to crds/, every other
+resource to templates/, and the images, replica counts and resource requests of workloads are moved into values.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p := parser.New(parser.WithFileIO(fileIO()), parser.WithSeparateClusterScoped(chartSeparateCluster))
+
+		var stdin *os.File
+		if s, err := os.Stdin.Stat(); err == nil && (s.Mode()&os.ModeCharDevice) == 0 {
+			stdin = os.Stdin
+		}
+
+		for _, a := range args {
+			chartInputFiles = append(chartInputFiles, a)
+		}
+
+		if err := p.Chart(chartInputFiles, stdin, chartOutputPath, chartOptions); err != nil {
+			log.Fatal(err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(chartCmd)
+	chartCmd.Flags().StringSliceVarP(&chartInputFiles, "input", "i", chartInputFiles, "provide /path/to/input/ or input.yaml")
+	chartCmd.Flags().StringVarP(&chartOutputPath, "output", "o", chartOutputPath, "provide /path/to/chart/dir")
+	chartCmd.Flags().StringVar(&chartOptions.Name, "name", chartOptions.Name, "chart name, defaults to the base of the output directory")
+	chartCmd.Flags().StringVar(&chartOptions.Description, "description", chartOptions.Description, "chart description")
+	chartCmd.Flags().StringVar(&chartOptions.Version, "version", chartOptions.Version, "chart version (default 0.1.0)")
+	chartCmd.Flags().StringVar(&chartOptions.AppVersion, "app-version", chartOptions.AppVersion, "version of the application the chart deploys")
+	chartCmd.Flags().BoolVar(&chartSeparateCluster, "separate-cluster", chartSeparateCluster, "write cluster-scoped resources into templates/cluster/")
+	chartCmd.MarkFlagRequired("output")
+}
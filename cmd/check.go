@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/kdwils/splinter/pkg/policy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkInputFiles  []string
+	checkPolicyFiles []string
+	checkFormat      string
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "evaluate CEL policies against manifests",
+	Long: `evaluate CEL policies against manifests without a cluster
+
+Policies are read from --policy files and the check.policies files of the config file:
+
+policies:
+  - name: team-label
+    match:
+      kinds: [Deployment]
+    validations:
+      - expression: "has(object.metadata.labels) && 'team' in object.metadata.labels"
+        message: every Deployment must have a team label`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rules, err := policyRules(append(cfg.Check.Policies, checkPolicyFiles...))
+		if err != nil {
+			return err
+		}
+
+		p := parser.New(parser.WithFileIO(fileIO()))
+
+		var stdin *os.File
+		if s, err := os.Stdin.Stat(); err == nil && (s.Mode()&os.ModeCharDevice) == 0 {
+			stdin = os.Stdin
+		}
+
+		for _, a := range args {
+			checkInputFiles = append(checkInputFiles, a)
+		}
+
+		findings, err := p.Lint(checkInputFiles, stdin, rules...)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := writeFindings(cmd.OutOrStdout(), checkFormat, rules, findings); err != nil {
+			log.Fatal(err)
+		}
+		if len(findings) > 0 {
+			os.Exit(1)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().StringSliceVarP(&checkInputFiles, "input", "i", checkInputFiles, "provide /path/to/input/ or input.yaml")
+	checkCmd.Flags().StringSliceVarP(&checkPolicyFiles, "policy", "p", checkPolicyFiles, "files containing CEL policies")
+	checkCmd.Flags().StringVarP(&checkFormat, "format", "f", "text", "output format, one of text, json or sarif")
+}
+
+// policyRules reads and compiles the policies of every file into lint rules
+func policyRules(files []string) ([]parser.Rule, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no policy files, pass --policy or set check.policies in the config file")
+	}
+
+	rules := make([]parser.Rule, 0)
+	for _, f := range files {
+		file, err := os.Open(f)
+		if err != nil {
+			return nil, err
+		}
+
+		policies, err := policy.Read(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+
+		for _, p := range policies {
+			compiled, err := policy.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f, err)
+			}
+			rules = append(rules, parser.PolicyRule(compiled))
+		}
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/kdwils/splinter/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deprecationsInputFiles        []string
+	deprecationsKubernetesVersion string
+	deprecationsFormat            string
+)
+
+// deprecationsCmd represents the deprecations command
+var deprecationsCmd = &cobra.Command{
+	Use:   "deprecations",
+	Short: "find resources using deprecated or removed api versions",
+	Long:  `find resources using api versions that are deprecated or removed in a target kubernetes version`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p := parser.New(parser.WithFileIO(fileIO()))
+
+		var stdin *os.File
+		if s, err := os.Stdin.Stat(); err == nil && (s.Mode()&os.ModeCharDevice) == 0 {
+			stdin = os.Stdin
+		}
+
+		for _, a := range args {
+			deprecationsInputFiles = append(deprecationsInputFiles, a)
+		}
+
+		deprecations, err := p.Deprecations(deprecationsInputFiles, stdin, deprecationsKubernetesVersion)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := writeDeprecations(cmd.OutOrStdout(), deprecationsFormat, deprecations); err != nil {
+			log.Fatal(err)
+		}
+		if len(deprecations) > 0 {
+			os.Exit(1)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deprecationsCmd)
+	deprecationsCmd.Flags().StringSliceVarP(&deprecationsInputFiles, "input", "i", deprecationsInputFiles, "provide /path/to/input/ or input.yaml")
+	deprecationsCmd.Flags().StringVar(&deprecationsKubernetesVersion, "kubernetes-version", schema.DefaultVersion, "kubernetes version to check against")
+	deprecationsCmd.Flags().StringVarP(&deprecationsFormat, "format", "f", "text", "output format, one of text or json")
+}
+
+func writeDeprecations(w io.Writer, format string, deprecations []parser.Deprecation) error {
+	switch format {
+	case "json":
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(deprecations)
+	case "text":
+		for _, d := range deprecations {
+			fmt.Fprintln(w, d.String())
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffSummary bool
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <from> <to>",
+	Short: "show the resources that differ between two sets of manifests",
+	Long: `show the resources that differ between two sets of manifests as unified diffs of their yaml. Resources are
+matched by api group, kind, namespace and name, and the command exits with 1 when anything differs.
+
+Either side may be a file, a directory, an archive or git:<rev>:<path>, which reads the path at a revision of the
+repository containing the working directory without checking it out. Paths starting with ./ or ../ are relative to
+the working directory, others to the root of the repository:
+
+  splinter diff git:main:deploy/ deploy/
+  splinter diff git:HEAD~1:./ ./`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p := parser.New(parser.WithFileIO(fileIO()))
+
+		diffs, err := p.Diff([]string{args[0]}, []string{args[1]})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		writeDiffs(cmd.OutOrStdout(), diffs, diffSummary)
+		if len(diffs) > 0 {
+			os.Exit(1)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().BoolVar(&diffSummary, "summary", false, "only list the added, removed and changed resources")
+}
+
+func writeDiffs(w io.Writer, diffs []parser.ResourceDiff, summary bool) {
+	for _, d := range diffs {
+		if summary {
+			fmt.Fprintf(w, "%-8s %s\n", d.Status, d.Resource)
+			continue
+		}
+		fmt.Fprint(w, d.Unified())
+	}
+	if summary || len(diffs) == 0 {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, d := range diffs {
+		counts[d.Status]++
+	}
+	parts := make([]string, 0, 3)
+	for _, status := range []string{parser.DiffAdded, parser.DiffChanged, parser.DiffRemoved} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	fmt.Fprintf(w, "\n%s\n", strings.Join(parts, ", "))
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fnAgeRecipients string
+	fnAgeIdentity   string
+)
+
+// fnCmd represents the fn command
+var fnCmd = &cobra.Command{
+	Use:   "fn",
+	Short: "run as a KRM function, reading a ResourceList from stdin",
+	Long: `run as a KRM function, reading a ResourceList from stdin and writing it to stdout
+
+Every item is annotated with config.kubernetes.io/path and config.kubernetes.io/index following the split
+layout, so kpt and kustomize write the items to files the way split would. The functionConfig is a ConfigMap
+or any resource with a spec:
+
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: splinter
+data:
+  path: manifests
+  separateCRDs: "true"
+  separateClusterScoped: "true"
+  images: nginx=registry.local/nginx:1.26
+  redactSecrets: "true"
+  migrateAPIs: "1.29"
+  lint: "true"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := make([]parser.ParserOpt, 0)
+
+		if fnAgeIdentity != "" {
+			d, err := loadDecrypter(fnAgeIdentity)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, parser.WithDecryption(d))
+		}
+
+		if fnAgeRecipients != "" {
+			e, err := loadEncrypter(fnAgeRecipients)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, parser.WithTransforms(parser.EncryptSecretsTransform(e)))
+		}
+
+		rules := lintRules()
+
+		p := parser.New(opts...)
+		if err := p.Function(os.Stdin, cmd.OutOrStdout(), rules...); err != nil {
+			log.Fatal(err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fnCmd)
+	fnCmd.Flags().StringVar(&fnAgeRecipients, "age-recipients", fnAgeRecipients, "encrypt Secret data and stringData values in the sops format for the age public keys listed in this file")
+	fnCmd.Flags().StringVar(&fnAgeIdentity, "age-identity", fnAgeIdentity, "decrypt sops encrypted items with the age identities in this file")
+}
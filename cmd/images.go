@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	imagesInputFiles []string
+	imagesFormat     string
+)
+
+// imagesCmd represents the images command
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "list every container image referenced by the manifests",
+	Long:  `list every container image referenced by the manifests along with the resources using it`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p := parser.New(parser.WithFileIO(fileIO()))
+
+		var stdin *os.File
+		if s, err := os.Stdin.Stat(); err == nil && (s.Mode()&os.ModeCharDevice) == 0 {
+			stdin = os.Stdin
+		}
+
+		for _, a := range args {
+			imagesInputFiles = append(imagesInputFiles, a)
+		}
+
+		usages, err := p.Images(imagesInputFiles, stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := writeImages(cmd.OutOrStdout(), imagesFormat, usages); err != nil {
+			log.Fatal(err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(imagesCmd)
+	imagesCmd.Flags().StringSliceVarP(&imagesInputFiles, "input", "i", imagesInputFiles, "provide /path/to/input/ or input.yaml")
+	imagesCmd.Flags().StringVarP(&imagesFormat, "format", "f", "text", "output format, one of text or json")
+}
+
+func writeImages(w io.Writer, format string, usages []parser.ImageUsage) error {
+	switch format {
+	case "json":
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(usages)
+	case "text":
+		for _, u := range usages {
+			fmt.Fprintln(w, u.Image)
+			for _, r := range u.Resources {
+				fmt.Fprintf(w, "  %s\n", r)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// imageOverrides parses every --image flag value into a parser.ImageOverride
+func imageOverrides(values []string) ([]parser.ImageOverride, error) {
+	overrides := make([]parser.ImageOverride, 0, len(values))
+	for _, v := range values {
+		o, err := parser.ParseImageOverride(v)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, nil
+}
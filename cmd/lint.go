@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintInputFiles []string
+	lintFormat     string
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "check manifests against best practice rules",
+	Long: `check manifests against best practice rules
+
+Rules are enabled by default and can be turned off in the config file:
+
+lint:
+  rules:
+    latest-tag: false`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p := parser.New(parser.WithFileIO(fileIO()))
+
+		var stdin *os.File
+		if s, err := os.Stdin.Stat(); err == nil && (s.Mode()&os.ModeCharDevice) == 0 {
+			stdin = os.Stdin
+		}
+
+		for _, a := range args {
+			lintInputFiles = append(lintInputFiles, a)
+		}
+
+		rules := lintRules()
+
+		findings, err := p.Lint(lintInputFiles, stdin, rules...)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := writeFindings(cmd.OutOrStdout(), lintFormat, rules, findings); err != nil {
+			log.Fatal(err)
+		}
+		if len(findings) > 0 {
+			os.Exit(1)
+		}
+
+		return nil
+	},
+}
+
+// lintRules returns the built-in rules enabled in the config
+func lintRules() []parser.Rule {
+	rules := make([]parser.Rule, 0)
+	for _, r := range parser.DefaultRules() {
+		if cfg.Lint.Enabled(r.ID()) {
+			rules = append(rules, r)
+		}
+	}
+	for _, r := range parser.OptionalRules() {
+		if cfg.Lint.EnabledOptional(r.ID()) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringSliceVarP(&lintInputFiles, "input", "i", lintInputFiles, "provide /path/to/input/ or input.yaml")
+	lintCmd.Flags().StringVarP(&lintFormat, "format", "f", "text", "output format, one of text, json or sarif")
+}
@@ -11,8 +11,22 @@ import (
 var (
 	mergeInputFiles       []string
 	mergeOutputPath       string
+	mergeStream           bool
 	mergeIncludeKustomize bool
 	mergeExclusions       []string
+	mergeImages           []string
+	mergeExtractData      bool
+	mergeValidate         bool
+	mergeMigrate          bool
+	mergeCheckRefs        bool
+	mergeRedactSecrets    bool
+	mergeFailOnSecrets    bool
+	mergeAgeRecipients    string
+	mergePlugins          []string
+	mergeNoPlugins        bool
+	mergeAgeIdentity      string
+	mergeKubeVersion      string
+	mergeCRDFiles         []string
 )
 
 // mergeCmd represents the merge command
@@ -21,7 +35,69 @@ var mergeCmd = &cobra.Command{
 	Short: "merge split manifests back together",
 	Long:  `merge split manifests back together`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p := parser.New()
+		overrides, err := imageOverrides(mergeImages)
+		if err != nil {
+			return err
+		}
+
+		opts := []parser.ParserOpt{parser.WithFileIO(fileIO())}
+		if len(overrides) > 0 {
+			opts = append(opts, parser.WithTransforms(parser.ImageOverrideTransform(overrides...)))
+		}
+
+		opts = append(opts, parser.WithExtractData(mergeExtractData))
+
+		opts = append(opts,
+			parser.WithReferenceCheck(mergeCheckRefs),
+			parser.WithFailOnSecrets(mergeFailOnSecrets),
+		)
+
+		if mergeRedactSecrets {
+			opts = append(opts, parser.WithTransforms(parser.RedactSecretsTransform()))
+		}
+
+		if mergeMigrate {
+			migrate, err := parser.MigrateAPIsTransform(mergeKubeVersion)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, parser.WithTransforms(migrate))
+		}
+
+		if !mergeNoPlugins {
+			plugins, err := pluginTransforms(mergePlugins)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, parser.WithTransforms(plugins...))
+		}
+
+		if mergeAgeIdentity != "" {
+			d, err := loadDecrypter(mergeAgeIdentity)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, parser.WithDecryption(d))
+		}
+
+		// encryption runs last so no other transform sees the encrypted values
+		if mergeAgeRecipients != "" {
+			e, err := loadEncrypter(mergeAgeRecipients)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, parser.WithTransforms(parser.EncryptSecretsTransform(e)))
+		}
+
+		if mergeValidate {
+			schemas, err := loadSchemas(mergeKubeVersion, mergeCRDFiles)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, parser.WithSchemas(schemas))
+		}
+
+		p := parser.New(opts...)
 
 		var stdin *os.File
 		// shoutout https://stackoverflow.com/questions/22744443/check-if-there-is-something-to-read-on-stdin-in-golang
@@ -33,7 +109,11 @@ var mergeCmd = &cobra.Command{
 			mergeInputFiles = append(mergeInputFiles, a)
 		}
 
-		err := p.Merge(mergeInputFiles, stdin, mergeOutputPath)
+		if mergeStream {
+			err = p.MergeStream(mergeInputFiles, stdin, mergeOutputPath)
+		} else {
+			err = p.Merge(mergeInputFiles, stdin, mergeOutputPath)
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -45,7 +125,20 @@ var mergeCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(mergeCmd)
 	mergeCmd.Flags().StringSliceVarP(&mergeInputFiles, "input", "i", mergeInputFiles, "provide /path/to/input/ or input.yaml")
+	mergeCmd.Flags().StringArrayVar(&mergeImages, "image", mergeImages, "override an image in the form name=newname:tag, may be repeated")
+	mergeCmd.Flags().BoolVar(&mergeExtractData, "extract-data", mergeExtractData, "rebuild ConfigMaps and Secrets from configMapGenerator and secretGenerator entries")
+	mergeCmd.Flags().BoolVar(&mergeValidate, "validate", mergeValidate, "validate the input against kubernetes schemas before writing")
+	mergeCmd.Flags().BoolVar(&mergeRedactSecrets, "redact-secrets", mergeRedactSecrets, "replace Secret data and stringData values with placeholders, keeping the keys")
+	mergeCmd.Flags().BoolVar(&mergeFailOnSecrets, "fail-on-secrets", mergeFailOnSecrets, "fail if a Secret with unredacted data would be written to the output")
+	mergeCmd.Flags().StringVar(&mergeAgeIdentity, "age-identity", mergeAgeIdentity, "decrypt sops encrypted Secrets with the age identities in this file")
+	mergeCmd.Flags().StringArrayVar(&mergePlugins, "plugin", mergePlugins, "run only the named plugin from the config file, may be repeated. Every plugin runs by default")
+	mergeCmd.Flags().BoolVar(&mergeNoPlugins, "no-plugins", mergeNoPlugins, "skip the plugins in the config file")
+	mergeCmd.Flags().StringVar(&mergeAgeRecipients, "age-recipients", mergeAgeRecipients, "encrypt Secret data and stringData values in the sops format for the age public keys listed in this file")
+	mergeCmd.Flags().BoolVar(&mergeCheckRefs, "check-refs", mergeCheckRefs, "fail when a resource references a ConfigMap, Secret, ServiceAccount, PersistentVolumeClaim, Service or Role missing from the input")
+	mergeCmd.Flags().BoolVar(&mergeMigrate, "migrate", mergeMigrate, "move resources off api versions deprecated in --kubernetes-version when only the api version has to change")
+	addSchemaFlags(mergeCmd, &mergeKubeVersion, &mergeCRDFiles)
 	mergeCmd.Flags().StringSliceVarP(&mergeExclusions, "exclusions", "e", mergeExclusions, "files or directories to exclude")
 	mergeCmd.Flags().BoolVarP(&mergeIncludeKustomize, "kustomize", "k", false, "spit out a kustomization.yaml")
+	mergeCmd.Flags().BoolVar(&mergeStream, "stream", mergeStream, "write each resource as soon as it is read instead of reading the whole input first, for inputs too large to hold in memory. Transforms run on one resource at a time")
 	mergeCmd.Flags().StringVarP(&mergeOutputPath, "output", "o", mergeOutputPath, "provide /path/to/output/file.yaml")
 }
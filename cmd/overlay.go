@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	overlayBaseFiles    []string
+	overlayVariantFiles []string
+	overlayOutputPath   string
+	overlayOptions      parser.OverlayOptions
+)
+
+// overlayCmd represents the overlay command
+var overlayCmd = &cobra.Command{
+	Use:   "overlay",
+	Short: "generate a kustomize base and overlay from two renders",
+	Long: `generate a kustomize base and overlay from two renders of the same manifests. The base manifests are split
+into base/, and overlays/<name>/ holds a kustomization whose patches turn the base into the variant`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p := parser.New(parser.WithFileIO(fileIO()))
+
+		if err := p.Overlay(overlayBaseFiles, overlayVariantFiles, overlayOutputPath, overlayOptions); err != nil {
+			log.Fatal(err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(overlayCmd)
+	overlayCmd.Flags().StringSliceVar(&overlayBaseFiles, "base", overlayBaseFiles, "provide /path/to/base/ or base.yaml")
+	overlayCmd.Flags().StringSliceVar(&overlayVariantFiles, "variant", overlayVariantFiles, "provide /path/to/variant/ or variant.yaml")
+	overlayCmd.Flags().StringVarP(&overlayOutputPath, "output", "o", overlayOutputPath, "provide /path/to/output/dir")
+	overlayCmd.Flags().StringVar(&overlayOptions.Name, "name", overlayOptions.Name, "name of the overlay directory under overlays/ (default variant)")
+	overlayCmd.Flags().StringVar(&overlayOptions.PatchFormat, "patch-format", overlayOptions.PatchFormat, "patch format, one of strategic or json6902. strategic falls back to json6902 for changes it cannot express (default strategic)")
+	overlayCmd.MarkFlagRequired("base")
+	overlayCmd.MarkFlagRequired("variant")
+	overlayCmd.MarkFlagRequired("output")
+}
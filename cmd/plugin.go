@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/kdwils/splinter/pkg/config"
+)
+
+// pluginTransforms returns a transform for every plugin in the config file, limited to the named plugins when any are given
+func pluginTransforms(names []string) ([]parser.Transform, error) {
+	for _, name := range names {
+		if !slices.ContainsFunc(cfg.Plugins, func(p config.Plugin) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("unknown plugin %q", name)
+		}
+	}
+
+	transforms := make([]parser.Transform, 0, len(cfg.Plugins))
+	for _, p := range cfg.Plugins {
+		if len(names) > 0 && !slices.Contains(names, p.Name) {
+			continue
+		}
+
+		t, err := parser.PluginTransform(parser.Plugin{
+			Name:           p.Name,
+			Command:        p.Command,
+			Args:           p.Args,
+			Env:            p.Env,
+			Format:         p.Format,
+			FunctionConfig: p.FunctionConfig,
+		})
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, t)
+	}
+
+	return transforms, nil
+}
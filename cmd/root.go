@@ -3,17 +3,24 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/kdwils/splinter/pkg/config"
+	"github.com/kdwils/splinter/pkg/fio"
 	"github.com/spf13/cobra"
 )
 
 var (
-	cfgFile    string
-	output     string
-	input      []string
-	kustomize  bool
-	exclusions []string
-	merge      bool
+	cfgFile     string
+	output      string
+	input       []string
+	kustomize   bool
+	exclusions  []string
+	merge       bool
+	cfg         config.Config
+	offline     bool
+	cacheDir    string
+	httpTimeout time.Duration
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -22,6 +29,11 @@ var rootCmd = &cobra.Command{
 	Short:        "cli to manipulate kubernetes manifest files",
 	Long:         `cli to manipulate kubernetes manifest files`,
 	SilenceUsage: false,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		cfg, err = config.Load(cfgFile)
+		return err
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -35,5 +47,13 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.splinter.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "read http and https inputs only from the cache")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", fio.DefaultCacheDir(), "directory http and https inputs are cached in")
+	rootCmd.PersistentFlags().DurationVar(&httpTimeout, "http-timeout", fio.DefaultTimeout, "how long fetching an http or https input may take, 0 for no limit")
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+// fileIO returns the FileIO commands read their input with, fetching http and https inputs through the cache
+func fileIO() fio.FileIO {
+	return fio.NewHTTP(fio.NewDefaultFileIO(), fio.WithCacheDir(cacheDir), fio.WithOffline(offline), fio.WithTimeout(httpTimeout))
+}
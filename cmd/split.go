@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"cmp"
+	"errors"
+	"io"
 	"log"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/kdwils/splinter/parser"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -13,7 +19,36 @@ var (
 	splitOutputPath       string
 	splitIncludeKustomize bool
 	splitExclusions       []string
+	splitImages           []string
+	splitExtractData      bool
+	splitValidate         bool
+	splitMigrate          bool
+	splitCheckRefs        bool
+	splitRedactSecrets    bool
+	splitFailOnSecrets    bool
+	splitSecretsDir       string
+	splitAgeRecipients    string
+	splitPlugins          []string
+	splitNoPlugins        bool
+	splitAgeIdentity      string
+	splitKubeVersion      string
+	splitCRDFiles         []string
+	splitSeparateCRDs     bool
+	splitSeparateCluster  bool
 	splitCreateKustomize  bool
+	splitArgoCD           parser.ArgoCDOptions
+	splitFlux             bool
+	splitFluxOptions      parser.FluxOptions
+	splitKustomization    parser.KustomizationOptions
+	splitKustomizeImages  []string
+	splitUpdateKustomize  bool
+	splitLock             bool
+	splitStream           bool
+)
+
+var (
+	errLockNeedsDir   = errors.New("--lock needs an output directory")
+	errStreamNeedsDir = errors.New("--stream needs an output directory and cannot be combined with --lock")
 )
 
 // splitCmd represents the split command
@@ -22,9 +57,12 @@ var splitCmd = &cobra.Command{
 	Short: "split a single kubernetes manifest into many",
 	Long:  `split a single kubernetes manifest into many`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p := parser.New()
+		p, err := splitParser(cmd)
+		if err != nil {
+			return err
+		}
 
-		var stdin *os.File
+		var stdin io.Reader
 		// shoutout https://stackoverflow.com/questions/22744443/check-if-there-is-something-to-read-on-stdin-in-golang
 		if s, err := os.Stdin.Stat(); err == nil && (s.Mode()&os.ModeCharDevice) == 0 {
 			stdin = os.Stdin
@@ -34,7 +72,22 @@ var splitCmd = &cobra.Command{
 			splitInputFiles = append(splitInputFiles, a)
 		}
 
-		err := p.Split(splitInputFiles, stdin, splitOutputPath, splitCreateKustomize)
+		switch format := parser.ArchiveFormat(splitOutputPath); {
+		case splitLock && (splitOutputPath == "-" || format != ""):
+			err = errLockNeedsDir
+		case splitStream && (splitLock || splitOutputPath == "-" || format != ""):
+			err = errStreamNeedsDir
+		case splitStream:
+			err = p.SplitStream(splitInputFiles, stdin, splitOutputPath, splitCreateKustomize)
+		case splitOutputPath == "-":
+			err = p.SplitArchive(splitInputFiles, stdin, cmd.OutOrStdout(), parser.ArchiveTar, splitCreateKustomize)
+		case format != "":
+			err = splitArchive(p, stdin, format)
+		case splitLock:
+			_, err = p.SplitLocked(splitInputFiles, stdin, splitOutputPath, splitCreateKustomize, lockOptions(cmd))
+		default:
+			err = p.Split(splitInputFiles, stdin, splitOutputPath, splitCreateKustomize)
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -43,12 +96,204 @@ var splitCmd = &cobra.Command{
 	},
 }
 
+// splitParser builds the parser split runs with from its flags
+func splitParser(cmd *cobra.Command) (*parser.Parser, error) {
+	overrides, err := imageOverrides(splitImages)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []parser.ParserOpt{parser.WithFileIO(fileIO())}
+	if len(overrides) > 0 {
+		opts = append(opts, parser.WithTransforms(parser.ImageOverrideTransform(overrides...)))
+	}
+
+	splitKustomization.Images, err = imageOverrides(splitKustomizeImages)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts,
+		parser.WithKustomization(splitKustomization),
+		parser.WithKustomizationUpdate(splitUpdateKustomize),
+		parser.WithExtractData(splitExtractData),
+		parser.WithSeparateCRDs(splitSeparateCRDs),
+		parser.WithSeparateClusterScoped(splitSeparateCluster),
+	)
+
+	opts = append(opts,
+		parser.WithReferenceCheck(splitCheckRefs),
+		parser.WithFailOnSecrets(splitFailOnSecrets),
+		parser.WithSecretsDir(splitSecretsDir),
+	)
+
+	if splitRedactSecrets {
+		opts = append(opts, parser.WithTransforms(parser.RedactSecretsTransform()))
+	}
+
+	if splitMigrate {
+		migrate, err := parser.MigrateAPIsTransform(splitKubeVersion)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, parser.WithTransforms(migrate))
+	}
+
+	if splitArgoCD.Kind != "" {
+		opts = append(opts, parser.WithArgoCD(argoCDOptions(splitArgoCD)))
+	}
+
+	if splitFlux {
+		opts = append(opts, parser.WithFlux(fluxOptions(splitFluxOptions, cmd.Flags().Changed("flux-prune"))))
+	}
+
+	if !splitNoPlugins {
+		plugins, err := pluginTransforms(splitPlugins)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, parser.WithTransforms(plugins...))
+	}
+
+	if splitAgeIdentity != "" {
+		d, err := loadDecrypter(splitAgeIdentity)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, parser.WithDecryption(d))
+	}
+
+	// encryption runs last so no other transform sees the encrypted values
+	if splitAgeRecipients != "" {
+		e, err := loadEncrypter(splitAgeRecipients)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, parser.WithTransforms(parser.EncryptSecretsTransform(e)))
+	}
+
+	if splitValidate {
+		schemas, err := loadSchemas(splitKubeVersion, splitCRDFiles)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, parser.WithSchemas(schemas))
+	}
+
+	return parser.New(opts...), nil
+}
+
+// splitArchive writes the split output into the archive named by the output path
+func splitArchive(p *parser.Parser, stdin io.Reader, format string) error {
+	f, err := os.Create(splitOutputPath)
+	if err != nil {
+		return err
+	}
+
+	if err := p.SplitArchive(splitInputFiles, stdin, f, format, splitCreateKustomize); err != nil {
+		f.Close()
+		os.Remove(splitOutputPath)
+		return err
+	}
+
+	return f.Close()
+}
+
+// argoCDOptions fills the options not set by flags from the config file
+func argoCDOptions(opts parser.ArgoCDOptions) parser.ArgoCDOptions {
+	opts.Namespace = cmp.Or(opts.Namespace, cfg.ArgoCD.Namespace)
+	opts.RepoURL = cmp.Or(opts.RepoURL, cfg.ArgoCD.RepoURL)
+	opts.TargetRevision = cmp.Or(opts.TargetRevision, cfg.ArgoCD.TargetRevision)
+	opts.Server = cmp.Or(opts.Server, cfg.ArgoCD.Server)
+	opts.Project = cmp.Or(opts.Project, cfg.ArgoCD.Project)
+	return opts
+}
+
+// fluxOptions fills the options not set by flags from the config file
+func fluxOptions(opts parser.FluxOptions, pruneSet bool) parser.FluxOptions {
+	opts.Namespace = cmp.Or(opts.Namespace, cfg.Flux.Namespace)
+	opts.SourceKind = cmp.Or(opts.SourceKind, cfg.Flux.SourceRef.Kind)
+	opts.SourceName = cmp.Or(opts.SourceName, cfg.Flux.SourceRef.Name)
+	opts.SourceNamespace = cmp.Or(opts.SourceNamespace, cfg.Flux.SourceRef.Namespace)
+	opts.Interval = cmp.Or(opts.Interval, cfg.Flux.Interval)
+	if !pruneSet && cfg.Flux.Prune != nil {
+		opts.Prune = *cfg.Flux.Prune
+	}
+	if len(opts.DependsOn) == 0 {
+		opts.DependsOn = cfg.Flux.DependsOn
+	}
+	return opts
+}
+
 func init() {
 	rootCmd.AddCommand(splitCmd)
 
 	splitCmd.Flags().StringSliceVarP(&splitInputFiles, "input", "i", splitInputFiles, "provide /path/to/input/ or input.yaml")
+	splitCmd.Flags().StringArrayVar(&splitImages, "image", splitImages, "override an image in the form name=newname:tag, may be repeated")
+	splitCmd.Flags().BoolVar(&splitExtractData, "extract-data", splitExtractData, "write ConfigMap and Secret data as files and reference them with generators in the kustomization.yaml")
+	splitCmd.Flags().BoolVar(&splitSeparateCRDs, "separate-crds", splitSeparateCRDs, "write CustomResourceDefinitions into a crds/ directory")
+	splitCmd.Flags().BoolVar(&splitSeparateCluster, "separate-cluster", splitSeparateCluster, "write cluster-scoped resources into a cluster/ directory")
+	splitCmd.Flags().BoolVar(&splitValidate, "validate", splitValidate, "validate the input against kubernetes schemas before writing")
+	splitCmd.Flags().BoolVar(&splitRedactSecrets, "redact-secrets", splitRedactSecrets, "replace Secret data and stringData values with placeholders, keeping the keys")
+	splitCmd.Flags().BoolVar(&splitFailOnSecrets, "fail-on-secrets", splitFailOnSecrets, "fail if a Secret with unredacted data would be written to the output")
+	splitCmd.Flags().StringVar(&splitSecretsDir, "secrets-dir", splitSecretsDir, "write Secrets into this directory instead of the output, leaving them out of the kustomization.yaml")
+	splitCmd.Flags().StringVar(&splitArgoCD.Kind, "argocd", splitArgoCD.Kind, "write an Argo CD Application per output directory, or a single ApplicationSet, into argocd/. One of application or applicationset")
+	splitCmd.Flags().StringVar(&splitArgoCD.Name, "argocd-name", splitArgoCD.Name, "prefix of the Argo CD application names, defaults to the base of the output directory")
+	splitCmd.Flags().StringVar(&splitArgoCD.Namespace, "argocd-namespace", splitArgoCD.Namespace, "namespace of the Argo CD resources (default argocd)")
+	splitCmd.Flags().StringVar(&splitArgoCD.RepoURL, "argocd-repo", splitArgoCD.RepoURL, "repository url the Argo CD applications deploy from")
+	splitCmd.Flags().StringVar(&splitArgoCD.TargetRevision, "argocd-revision", splitArgoCD.TargetRevision, "revision the Argo CD applications deploy (default HEAD)")
+	splitCmd.Flags().StringVar(&splitArgoCD.Path, "argocd-path", splitArgoCD.Path, "path of the output directory within the repository, required when the output path is absolute or outside the working directory, defaults to the output path")
+	splitCmd.Flags().StringVar(&splitArgoCD.Server, "argocd-server", splitArgoCD.Server, "destination cluster of the Argo CD applications (default https://kubernetes.default.svc)")
+	splitCmd.Flags().StringVar(&splitArgoCD.Project, "argocd-project", splitArgoCD.Project, "Argo CD project of the applications (default default)")
+	splitCmd.Flags().BoolVar(&splitFlux, "flux", splitFlux, "write a Flux Kustomization per output directory into flux/, each depending on the directories applied before it. Implies --kustomize")
+	splitCmd.Flags().StringVar(&splitFluxOptions.Name, "flux-name", splitFluxOptions.Name, "prefix of the Flux Kustomization names, defaults to the base of the output directory")
+	splitCmd.Flags().StringVar(&splitFluxOptions.Namespace, "flux-namespace", splitFluxOptions.Namespace, "namespace of the Flux Kustomizations (default flux-system)")
+	splitCmd.Flags().StringVar(&splitFluxOptions.Path, "flux-path", splitFluxOptions.Path, "path of the output directory within the repository, required when the output path is absolute or outside the working directory, defaults to the output path")
+	splitCmd.Flags().StringVar(&splitFluxOptions.SourceKind, "flux-source-kind", splitFluxOptions.SourceKind, "kind of the source the Flux Kustomizations read from (default GitRepository)")
+	splitCmd.Flags().StringVar(&splitFluxOptions.SourceName, "flux-source-name", splitFluxOptions.SourceName, "name of the source the Flux Kustomizations read from (default flux-system)")
+	splitCmd.Flags().StringVar(&splitFluxOptions.SourceNamespace, "flux-source-namespace", splitFluxOptions.SourceNamespace, "namespace of the source the Flux Kustomizations read from")
+	splitCmd.Flags().StringVar(&splitFluxOptions.Interval, "flux-interval", splitFluxOptions.Interval, "reconciliation interval of the Flux Kustomizations (default 10m)")
+	splitCmd.Flags().BoolVar(&splitFluxOptions.Prune, "flux-prune", true, "let Flux garbage collect resources removed from the output")
+	splitCmd.Flags().StringSliceVar(&splitFluxOptions.DependsOn, "flux-depends-on", splitFluxOptions.DependsOn, "Flux Kustomizations every generated Kustomization depends on")
+	splitCmd.Flags().StringArrayVar(&splitPlugins, "plugin", splitPlugins, "run only the named plugin from the config file, may be repeated. Every plugin runs by default")
+	splitCmd.Flags().BoolVar(&splitNoPlugins, "no-plugins", splitNoPlugins, "skip the plugins in the config file")
+	splitCmd.Flags().StringVar(&splitAgeRecipients, "age-recipients", splitAgeRecipients, "encrypt Secret data and stringData values in the sops format for the age public keys listed in this file")
+	splitCmd.Flags().StringVar(&splitAgeIdentity, "age-identity", splitAgeIdentity, "decrypt sops encrypted input with the age identities in this file")
+	splitCmd.Flags().BoolVar(&splitCheckRefs, "check-refs", splitCheckRefs, "fail when a resource references a ConfigMap, Secret, ServiceAccount, PersistentVolumeClaim, Service or Role missing from the input")
+	splitCmd.Flags().BoolVar(&splitMigrate, "migrate", splitMigrate, "move resources off api versions deprecated in --kubernetes-version when only the api version has to change")
+	addSchemaFlags(splitCmd, &splitKubeVersion, &splitCRDFiles)
 	splitCmd.Flags().StringSliceVarP(&splitExclusions, "exclusions", "e", splitExclusions, "files or directories to exclude")
 	splitCmd.Flags().BoolVarP(&splitCreateKustomize, "kustomize", "k", splitCreateKustomize, "spit out a kustomization.yaml")
-	splitCmd.Flags().StringVarP(&splitOutputPath, "output", "o", splitOutputPath, "provide /path/to/output/dir")
+	splitCmd.Flags().BoolVar(&splitUpdateKustomize, "update-kustomization", splitUpdateKustomize, "update the resources, generators and namespace of an existing kustomization in the output instead of overwriting it, keeping every other field and comment")
+	splitCmd.Flags().StringVar(&splitKustomization.FileName, "kustomization-file", splitKustomization.FileName, "name of the generated kustomization, one of kustomization.yaml, kustomization.yml or Kustomization (default kustomization.yaml)")
+	splitCmd.Flags().StringVar(&splitKustomization.Namespace, "kustomize-namespace", splitKustomization.Namespace, "namespace set by the generated kustomization")
+	splitCmd.Flags().BoolVar(&splitKustomization.HoistNamespace, "hoist-namespace", splitKustomization.HoistNamespace, "move a namespace shared by every namespaced resource out of the resources and into the generated kustomization")
+	splitCmd.Flags().StringVar(&splitKustomization.NamePrefix, "name-prefix", splitKustomization.NamePrefix, "namePrefix of the generated kustomization")
+	splitCmd.Flags().StringVar(&splitKustomization.NameSuffix, "name-suffix", splitKustomization.NameSuffix, "nameSuffix of the generated kustomization")
+	splitCmd.Flags().StringToStringVar(&splitKustomization.Labels, "label", splitKustomization.Labels, "label added by the generated kustomization in the form key=value, may be repeated")
+	splitCmd.Flags().StringToStringVar(&splitKustomization.CommonAnnotations, "annotation", splitKustomization.CommonAnnotations, "annotation added by the generated kustomization in the form key=value, may be repeated")
+	splitCmd.Flags().StringArrayVar(&splitKustomizeImages, "kustomize-image", splitKustomizeImages, "image override written to the generated kustomization in the form name=newname:tag, may be repeated")
+	splitCmd.Flags().StringVarP(&splitOutputPath, "output", "o", splitOutputPath, "provide /path/to/output/dir, an archive ending in .tar, .tar.gz, .tgz or .zip, or - for a tar stream on stdout")
+	splitCmd.Flags().BoolVar(&splitLock, "lock", splitLock, "write a splinter.lock into the output recording the inputs, the flags and the checksum of every file written, for splinter verify")
+	splitCmd.Flags().BoolVar(&splitStream, "stream", splitStream, "write each resource as soon as it is read instead of reading the whole input first, for inputs too large to hold in memory. Transforms run on one resource at a time")
 	splitCmd.MarkFlagRequired("output")
 }
+
+// lockOptions returns the flags split ran with, leaving out its input, output and --lock, so verify can run it again
+func lockOptions(cmd *cobra.Command) []string {
+	options := make([]string, 0)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if slices.Contains([]string{"input", "output", "lock"}, f.Name) || cmd.InheritedFlags().Lookup(f.Name) != nil {
+			return
+		}
+
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range s.GetSlice() {
+				options = append(options, "--"+f.Name+"="+v)
+			}
+			return
+		}
+		options = append(options, "--"+f.Name+"="+strings.Trim(f.Value.String(), "[]"))
+	})
+	return options
+}
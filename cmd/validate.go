@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/kdwils/splinter/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateInputFiles        []string
+	validateKubernetesVersion string
+	validateCRDFiles          []string
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "validate manifests against kubernetes schemas",
+	Long:  `validate manifests against the bundled kubernetes OpenAPI schemas and the schemas of CustomResourceDefinitions`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schemas, err := loadSchemas(validateKubernetesVersion, validateCRDFiles)
+		if err != nil {
+			return err
+		}
+
+		p := parser.New(parser.WithFileIO(fileIO()), parser.WithSchemas(schemas))
+
+		var stdin *os.File
+		if s, err := os.Stdin.Stat(); err == nil && (s.Mode()&os.ModeCharDevice) == 0 {
+			stdin = os.Stdin
+		}
+
+		for _, a := range args {
+			validateInputFiles = append(validateInputFiles, a)
+		}
+
+		errs, err := p.Validate(validateInputFiles, stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, e := range errs {
+			fmt.Fprintln(cmd.OutOrStdout(), e.Error())
+		}
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringSliceVarP(&validateInputFiles, "input", "i", validateInputFiles, "provide /path/to/input/ or input.yaml")
+	addSchemaFlags(validateCmd, &validateKubernetesVersion, &validateCRDFiles)
+}
+
+// addSchemaFlags registers the flags selecting the schemas used for validation
+func addSchemaFlags(cmd *cobra.Command, version *string, crdFiles *[]string) {
+	cmd.Flags().StringVar(version, "kubernetes-version", schema.DefaultVersion, fmt.Sprintf("kubernetes version to validate against, one of %v", schema.Versions()))
+	cmd.Flags().StringSliceVar(crdFiles, "crd", *crdFiles, "files containing CustomResourceDefinitions whose schemas are used for validation")
+}
+
+// loadSchemas loads the bundled schemas for a kubernetes version and adds the CRDs found in crdFiles
+func loadSchemas(version string, crdFiles []string) (*schema.Set, error) {
+	schemas, err := schema.Load(version)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range crdFiles {
+		file, err := os.Open(f)
+		if err != nil {
+			return nil, err
+		}
+
+		err = schemas.AddCRDs(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+	}
+
+	return schemas, nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/kdwils/splinter/parser"
+	"github.com/spf13/cobra"
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify <output dir>",
+	Short: "check split output against its splinter.lock",
+	Long: `check split output against the splinter.lock written by split --lock. The split recorded in the lock runs again
+in memory with the same inputs and flags, and every drift is reported: inputs whose digest changed, files the split no
+longer produces as the lock says, and files modified or missing on disk. Exits with 1 when anything drifted.
+
+Inputs are read relative to the working directory, as split read them. A lock recording stdin needs the same input
+piped to verify.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		lock, err := parser.New(parser.WithFileIO(fileIO())).ReadLock(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := splitCmd.ParseFlags(lock.Options); err != nil {
+			log.Fatal(fmt.Errorf("%s: %w", parser.LockFileName, err))
+		}
+		p, err := splitParser(splitCmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var stdin io.Reader
+		if s, err := os.Stdin.Stat(); err == nil && (s.Mode()&os.ModeCharDevice) == 0 {
+			stdin = os.Stdin
+		}
+
+		drifts, err := p.Verify(lock, args[0], stdin, splitCreateKustomize)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, d := range drifts {
+			fmt.Fprintln(cmd.OutOrStdout(), d)
+		}
+		if len(drifts) > 0 {
+			os.Exit(1)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
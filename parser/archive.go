@@ -0,0 +1,310 @@
+package parser
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kdwils/splinter/pkg/fio"
+)
+
+const (
+	ArchiveTar   = "tar"
+	ArchiveTarGz = "tgz"
+	ArchiveZip   = "zip"
+)
+
+var ErrInvalidArchiveFormat = errors.New("archive format must be tar, tgz or zip")
+
+// archiveModTime is the modification time of every archived file, so the same output always yields the same archive
+var archiveModTime = time.Unix(0, 0).UTC()
+
+// ArchiveFormat returns the archive format named by the extension of name, or an empty string when it is not an archive
+func ArchiveFormat(name string) string {
+	if fio.IsURL(name) {
+		name, _, _ = strings.Cut(name, "#")
+	}
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveTar
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip
+	default:
+		return ""
+	}
+}
+
+// SplitArchive splits the input like Split and writes the output tree into an archive of the given format instead of
+// a directory. Files are placed at the root of the archive.
+func (p *Parser) SplitArchive(inputFiles []string, stdin io.Reader, w io.Writer, format string, kustomize bool) error {
+	if !slices.Contains([]string{ArchiveTar, ArchiveTarGz, ArchiveZip}, format) {
+		return fmt.Errorf("%w: %q", ErrInvalidArchiveFormat, format)
+	}
+
+	resources, err := p.read(inputFiles, stdin)
+	if err != nil {
+		return err
+	}
+
+	// the archive starts out empty, so nothing is read from the working directory once the input is read. Secrets
+	// routed to a secrets directory are kept out of the archive, as they are kept out of an output directory.
+	out := fio.NewMemory()
+	ap := *p
+	ap.fio = outputFileIO{FileIO: p.fio, out: out, root: ".", through: p.secretsDir}
+	if err := ap.splitResources(resources, ".", kustomize); err != nil {
+		return err
+	}
+
+	return writeArchive(w, format, out)
+}
+
+// writeArchive writes every file of out into an archive
+func writeArchive(w io.Writer, format string, out *fio.Memory) error {
+	if format == ArchiveZip {
+		zw := zip.NewWriter(w)
+		for _, name := range out.Files() {
+			data, _ := out.ReadFile(name)
+			f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archiveModTime})
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write(data); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	}
+
+	var gz *gzip.Writer
+	if format == ArchiveTarGz {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	for _, name := range out.Files() {
+		data, _ := out.ReadFile(name)
+		header := &tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(data)),
+			ModTime:  archiveModTime,
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+// outputFileIO writes into memory while reading through to the FileIO it wraps, preferring files it has written.
+// Paths under root, when set, are only read from memory. Paths under through, when set, are written to the FileIO
+// it wraps instead.
+type outputFileIO struct {
+	fio.FileIO
+	out     *fio.Memory
+	root    string
+	through string
+}
+
+func (o outputFileIO) ReadFile(filename string) ([]byte, error) {
+	b, err := o.out.ReadFile(filename)
+	if err == nil || o.isolated(filename) {
+		return b, err
+	}
+	return o.FileIO.ReadFile(filename)
+}
+
+func (o outputFileIO) Stat(name string) (fs.FileInfo, error) {
+	info, err := o.out.Stat(name)
+	if err == nil || o.isolated(name) {
+		return info, err
+	}
+	return o.FileIO.Stat(name)
+}
+
+func (o outputFileIO) ReadDir(name string) ([]fs.DirEntry, error) {
+	if o.isolated(name) {
+		return o.out.ReadDir(name)
+	}
+	return o.FileIO.ReadDir(name)
+}
+
+// isolated reports whether name is only read from memory
+func (o outputFileIO) isolated(name string) bool {
+	return o.root != "" && withinDir(o.root, name)
+}
+
+// written reports whether name is written to the FileIO outputFileIO wraps
+func (o outputFileIO) written(name string) bool {
+	return o.through != "" && withinDir(o.through, name)
+}
+
+func (o outputFileIO) Create(name string) (io.WriteCloser, error) {
+	if o.written(name) {
+		return o.FileIO.Create(name)
+	}
+	if err := o.out.MkdirAll(path.Dir(name), 0o755); err != nil {
+		return nil, err
+	}
+	return o.out.Create(name)
+}
+
+func (o outputFileIO) MkdirAll(name string, perm fs.FileMode) error {
+	if o.written(name) {
+		return o.FileIO.MkdirAll(name, perm)
+	}
+	return o.out.MkdirAll(name, perm)
+}
+
+func (o outputFileIO) WriteFile(filename string, data []byte, perm fs.FileMode) error {
+	if o.written(filename) {
+		return o.FileIO.WriteFile(filename, data, perm)
+	}
+	if err := o.out.MkdirAll(path.Dir(filename), 0o755); err != nil {
+		return err
+	}
+	return o.out.WriteFile(filename, data, perm)
+}
+
+// archiveStreams returns a stream for every yaml file in the archive. Helm chart packages only contribute the files in
+// their crds directories, as everything else in a chart is a template.
+func (p *Parser) archiveStreams(name string) ([]Stream, error) {
+	b, err := p.fio.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []Stream
+	if ArchiveFormat(name) == ArchiveZip {
+		files, err = zipFiles(b)
+	} else {
+		files, err = tarFiles(b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	chart := slices.ContainsFunc(files, func(s Stream) bool { return isChartFile(s.Name) })
+
+	// every file is kept so generators of archived kustomizations can read theirs
+	archived := fio.NewMemory()
+	streams := make([]Stream, 0, len(files))
+	for _, f := range files {
+		data, err := io.ReadAll(f.Reader)
+		if err != nil {
+			return nil, err
+		}
+		archived.AddFile(f.Name, data)
+
+		ext := strings.ToLower(path.Ext(f.Name))
+		if ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		if chart && !slices.Contains(strings.Split(path.Dir(f.Name), "/"), crdsDir) {
+			continue
+		}
+		streams = append(streams, Stream{Name: name + ":" + f.Name, Reader: bytes.NewReader(data), files: archived, path: f.Name})
+	}
+
+	return streams, nil
+}
+
+// isChartFile reports whether name is the Chart.yaml of a packaged chart, at the root or in its top level directory
+func isChartFile(name string) bool {
+	return path.Base(name) == "Chart.yaml" && strings.Count(strings.Trim(name, "/"), "/") <= 1
+}
+
+// tarFiles returns the regular files of a tar archive, gzip compressed or not
+func tarFiles(b []byte) ([]Stream, error) {
+	var r io.Reader = bytes.NewReader(b)
+	if br := bufio.NewReader(r); isGzip(br) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	files := make([]Stream, 0)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, Stream{Name: path.Clean(header.Name), Reader: bytes.NewReader(data)})
+	}
+}
+
+// zipFiles returns the regular files of a zip archive
+func zipFiles(b []byte) ([]Stream, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]Stream, 0, len(zr.File))
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, Stream{Name: path.Clean(f.Name), Reader: bytes.NewReader(data)})
+	}
+
+	return files, nil
+}
+
+func isGzip(r *bufio.Reader) bool {
+	magic, err := r.Peek(2)
+	return err == nil && magic[0] == 0x1f && magic[1] == 0x8b
+}
@@ -0,0 +1,267 @@
+package parser
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kdwils/splinter/pkg/fio"
+)
+
+func TestArchiveFormat(t *testing.T) {
+	tests := map[string]string{
+		"out.tar":      ArchiveTar,
+		"out.tar.gz":   ArchiveTarGz,
+		"chart.TGZ":    ArchiveTarGz,
+		"bundle.zip":   ArchiveZip,
+		"out.yaml":     "",
+		"out":          "",
+		"charts.gz":    "",
+		"dir/file.tar": ArchiveTar,
+	}
+
+	for name, want := range tests {
+		if got := ArchiveFormat(name); got != want {
+			t.Errorf("ArchiveFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestParser_SplitArchive(t *testing.T) {
+	input := "kind: Service\nmetadata:\n  name: web\n---\nkind: Deployment\nmetadata:\n  name: web\n"
+	want := map[string]string{
+		"deployment.yaml":    "kind: Deployment\nmetadata:\n  name: web\n",
+		"kustomization.yaml": "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n  - deployment.yaml\n  - service.yaml\n",
+		"service.yaml":       "kind: Service\nmetadata:\n  name: web\n",
+	}
+
+	for _, format := range []string{ArchiveTar, ArchiveTarGz, ArchiveZip} {
+		t.Run(format, func(t *testing.T) {
+			m := fio.NewMemory()
+			m.AddFile("input.yaml", []byte(input))
+
+			var out bytes.Buffer
+			if err := New(WithFileIO(m)).SplitArchive([]string{"input.yaml"}, nil, &out, format, true); err != nil {
+				t.Fatalf("SplitArchive() error = %v", err)
+			}
+			if got := m.Files(); !slices.Equal(got, []string{"input.yaml"}) {
+				t.Errorf("SplitArchive() wrote %v to the file system", got)
+			}
+
+			var files []Stream
+			var err error
+			if format == ArchiveZip {
+				files, err = zipFiles(out.Bytes())
+			} else {
+				files, err = tarFiles(out.Bytes())
+			}
+			if err != nil {
+				t.Fatalf("reading archive: %v", err)
+			}
+
+			got := make(map[string]string, len(files))
+			for _, f := range files {
+				var buf bytes.Buffer
+				buf.ReadFrom(f.Reader)
+				got[f.Name] = buf.String()
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("archive = %v, want %v", got, want)
+			}
+		})
+	}
+
+	t.Run("ignores the working directory", func(t *testing.T) {
+		m := fio.NewMemory()
+		m.AddFile("input.yaml", []byte(input))
+		m.AddFile("kustomization.yaml", []byte("# not the output\nresources:\n  - local.yaml\n"))
+
+		var out bytes.Buffer
+		p := New(WithFileIO(m), WithKustomizationUpdate(true))
+		if err := p.SplitArchive([]string{"input.yaml"}, nil, &out, ArchiveTar, true); err != nil {
+			t.Fatalf("SplitArchive() error = %v", err)
+		}
+
+		files, err := tarFiles(out.Bytes())
+		if err != nil {
+			t.Fatalf("reading archive: %v", err)
+		}
+		for _, f := range files {
+			var buf bytes.Buffer
+			buf.ReadFrom(f.Reader)
+			if f.Name == "kustomization.yaml" && buf.String() != want["kustomization.yaml"] {
+				t.Errorf("kustomization.yaml = %q, want %q", buf.String(), want["kustomization.yaml"])
+			}
+		}
+	})
+
+	t.Run("merges extracted data", func(t *testing.T) {
+		m := fio.NewMemory()
+		m.AddFile("input.yaml", []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\ndata:\n  app.conf: archived\n"))
+
+		var out bytes.Buffer
+		if err := New(WithFileIO(m), WithExtractData(true)).SplitArchive([]string{"input.yaml"}, nil, &out, ArchiveTarGz, true); err != nil {
+			t.Fatalf("SplitArchive() error = %v", err)
+		}
+		m.AddFile("ex.tgz", out.Bytes())
+		// a local file at the same path must not be read in place of the archived one
+		m.AddFile("configmaps/settings/app.conf", []byte("local"))
+
+		if err := New(WithFileIO(m), WithExtractData(true)).Merge([]string{"ex.tgz"}, nil, "merged.yaml"); err != nil {
+			t.Fatalf("Merge() error = %v", err)
+		}
+		got, _ := m.Contents("merged.yaml")
+		if !strings.Contains(got, "app.conf: archived") {
+			t.Errorf("merged =\n%s\nwant the archived app.conf", got)
+		}
+	})
+
+	t.Run("writes secrets to the secrets directory", func(t *testing.T) {
+		m := fio.NewMemory()
+		m.AddFile("input.yaml", []byte(input+"---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\nstringData:\n  password: hunter2\n"))
+
+		var out bytes.Buffer
+		if err := New(WithFileIO(m), WithSecretsDir("secrets")).SplitArchive([]string{"input.yaml"}, nil, &out, ArchiveTar, true); err != nil {
+			t.Fatalf("SplitArchive() error = %v", err)
+		}
+		if got, _ := m.Contents("secrets/secret.yaml"); !strings.Contains(got, "password: hunter2") {
+			t.Errorf("secrets/secret.yaml = %q, want the secret", got)
+		}
+
+		files, err := tarFiles(out.Bytes())
+		if err != nil {
+			t.Fatalf("reading archive: %v", err)
+		}
+		names := make([]string, 0, len(files))
+		for _, f := range files {
+			names = append(names, f.Name)
+		}
+		if want := []string{"deployment.yaml", "kustomization.yaml", "service.yaml"}; !slices.Equal(names, want) {
+			t.Errorf("archive = %v, want %v", names, want)
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		if err := New().SplitArchive(nil, nil, &bytes.Buffer{}, "rar", false); !errors.Is(err, ErrInvalidArchiveFormat) {
+			t.Errorf("SplitArchive() error = %v, want %v", err, ErrInvalidArchiveFormat)
+		}
+	})
+}
+
+func TestParser_readArchives(t *testing.T) {
+	service := "kind: Service\nmetadata:\n  name: web\n"
+	crd := "kind: CustomResourceDefinition\nmetadata:\n  name: foos.example.com\n"
+
+	tests := []struct {
+		name  string
+		file  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name:  "tar",
+			file:  "bundle.tar",
+			files: map[string]string{"manifests/service.yaml": service, "README.md": "# bundle"},
+			want:  []string{"Service/web"},
+		},
+		{
+			name:  "tgz",
+			file:  "bundle.tgz",
+			files: map[string]string{"service.yml": service, "crds/crd.yaml": crd},
+			want:  []string{"CustomResourceDefinition/foos.example.com", "Service/web"},
+		},
+		{
+			name:  "zip",
+			file:  "bundle.zip",
+			files: map[string]string{"service.yaml": service},
+			want:  []string{"Service/web"},
+		},
+		{
+			name: "helm chart reads only crds",
+			file: "chart-0.1.0.tgz",
+			files: map[string]string{
+				"chart/Chart.yaml":             "apiVersion: v2\nname: chart\nversion: 0.1.0\n",
+				"chart/values.yaml":            "replicas: 1\n",
+				"chart/templates/svc.yaml":     "kind: Service\nmetadata:\n  name: {{ .Release.Name }}\n",
+				"chart/crds/crd.yaml":          crd,
+				"chart/templates/_helpers.tpl": "{{- define \"x\" -}}{{- end -}}",
+			},
+			want: []string{"CustomResourceDefinition/foos.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := fio.NewMemory()
+			m.AddFile(tt.file, newArchive(t, ArchiveFormat(tt.file), tt.files))
+
+			resources, err := New(WithFileIO(m)).read([]string{tt.file}, nil)
+			if err != nil {
+				t.Fatalf("read() error = %v", err)
+			}
+
+			got := make([]string, 0, len(resources))
+			for _, r := range resources {
+				got = append(got, r.Ref())
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("read() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newArchive(t *testing.T, format string, files map[string]string) []byte {
+	t.Helper()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var buf bytes.Buffer
+	if format == ArchiveZip {
+		zw := zip.NewWriter(&buf)
+		for _, name := range names {
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(files[name]))
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if format == ArchiveTarGz {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(files[name]))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	ArgoCDApplication    = "Application"
+	ArgoCDApplicationSet = "ApplicationSet"
+
+	argoCDDir             = "argocd"
+	argoCDAPIVersion      = "argoproj.io/v1alpha1"
+	argoCDSyncWave        = "argocd.argoproj.io/sync-wave"
+	defaultArgoCDRevision = "HEAD"
+	defaultArgoCDServer   = "https://kubernetes.default.svc"
+	defaultArgoCDProject  = "default"
+	defaultArgoCDNS       = "argocd"
+)
+
+var (
+	ErrMissingRepoURL    = errors.New("argo cd applications require a repo url")
+	ErrInvalidArgoCDKind = errors.New("argo cd kind must be Application or ApplicationSet")
+	ErrMissingArgoCDPath = errors.New("argo cd applications require a repository path when the output path is absolute or outside the working directory")
+)
+
+// ArgoCDOptions parameterizes the Argo CD resources generated on split. Empty fields fall back to
+// Argo CD's usual defaults.
+type ArgoCDOptions struct {
+	// Kind is Application for one Application per group or ApplicationSet for a single ApplicationSet with a
+	// list generator, matched case-insensitively
+	Kind string
+	// Name prefixes the application names, defaulting to the base of the output directory
+	Name string
+	// Namespace is the namespace the Argo CD resources are created in
+	Namespace      string
+	RepoURL        string
+	TargetRevision string
+	// Path is the output directory relative to the repository root, defaulting to the output path when it is relative
+	// and within the working directory
+	Path    string
+	Server  string
+	Project string
+}
+
+// WithArgoCD writes Argo CD resources deploying each group directory of the split output into an argocd directory.
+// With a kustomization, every group directory gets its own kustomization so the applications do not overlap.
+func WithArgoCD(opts ArgoCDOptions) ParserOpt {
+	return func(p *Parser) {
+		p.argoCD = &opts
+	}
+}
+
+// argoCDApplications returns the Argo CD resources for the groups of the split output
+func (p *Parser) argoCDApplications(outputPath string, groups map[string][]string, files map[string][]Resource) ([]Resource, error) {
+	opts := *p.argoCD
+	if opts.RepoURL == "" {
+		return nil, ErrMissingRepoURL
+	}
+	switch strings.ToLower(opts.Kind) {
+	case "", "application":
+		opts.Kind = ArgoCDApplication
+	case "applicationset":
+		opts.Kind = ArgoCDApplicationSet
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidArgoCDKind, opts.Kind)
+	}
+	if opts.Path == "" && !withinDir(".", outputPath) {
+		return nil, ErrMissingArgoCDPath
+	}
+	opts.Name = cmp.Or(opts.Name, path.Base(path.Clean(outputPath)))
+	opts.Path = cmp.Or(opts.Path, path.Clean(outputPath))
+	opts.Namespace = cmp.Or(opts.Namespace, defaultArgoCDNS)
+	opts.TargetRevision = cmp.Or(opts.TargetRevision, defaultArgoCDRevision)
+	opts.Server = cmp.Or(opts.Server, defaultArgoCDServer)
+	opts.Project = cmp.Or(opts.Project, defaultArgoCDProject)
+
+	names := sortedGroups(groups)
+
+	if opts.Kind == ArgoCDApplicationSet {
+		elements := make([]map[string]string, 0, len(names))
+		for _, group := range names {
+			elements = append(elements, map[string]string{
+				"name":      groupName(opts.Name, group),
+				"path":      path.Join(opts.Path, group),
+				"namespace": groupNamespace(groups[group], files),
+			})
+		}
+		return []Resource{newApplicationSet(opts, elements)}, nil
+	}
+
+	apps := make([]Resource, 0, len(names))
+	for _, group := range names {
+		app := newApplication(opts, groupName(opts.Name, group), path.Join(opts.Path, group), groupNamespace(groups[group], files))
+		if len(names) > 1 {
+			metadata, _ := asMap(app["metadata"])
+			metadata["annotations"] = Resource{argoCDSyncWave: strconv.Itoa(resourceOrder(group + "/"))}
+		}
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+func newApplication(opts ArgoCDOptions, name, sourcePath, namespace string) Resource {
+	return Resource{
+		"apiVersion": argoCDAPIVersion,
+		"kind":       ArgoCDApplication,
+		"metadata":   Resource{"name": name, "namespace": opts.Namespace},
+		"spec":       applicationSpec(opts, sourcePath, namespace),
+	}
+}
+
+func newApplicationSet(opts ArgoCDOptions, elements []map[string]string) Resource {
+	return Resource{
+		"apiVersion": argoCDAPIVersion,
+		"kind":       ArgoCDApplicationSet,
+		"metadata":   Resource{"name": opts.Name, "namespace": opts.Namespace},
+		"spec": Resource{
+			"generators": []any{
+				Resource{"list": Resource{"elements": elements}},
+			},
+			"template": Resource{
+				"metadata": Resource{"name": "{{name}}"},
+				"spec":     applicationSpec(opts, "{{path}}", "{{namespace}}"),
+			},
+		},
+	}
+}
+
+func applicationSpec(opts ArgoCDOptions, sourcePath, namespace string) Resource {
+	destination := Resource{"server": opts.Server}
+	if namespace != "" {
+		destination["namespace"] = namespace
+	}
+
+	return Resource{
+		"project": opts.Project,
+		"source": Resource{
+			"repoURL":        opts.RepoURL,
+			"targetRevision": opts.TargetRevision,
+			"path":           sourcePath,
+		},
+		"destination": destination,
+	}
+}
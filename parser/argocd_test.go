@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"cmp"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParser_argoCDApplications(t *testing.T) {
+	files := map[string][]Resource{
+		"crds/customresourcedefinition.yaml": {{"kind": "CustomResourceDefinition", "metadata": Resource{"name": "widgets.example.com"}}},
+		"service.yaml":                       {{"kind": "Service", "metadata": Resource{"name": "web", "namespace": "app"}}},
+	}
+	groups := outputGroups(files)
+
+	spec := func(path, namespace string) Resource {
+		destination := Resource{"server": defaultArgoCDServer}
+		if namespace != "" {
+			destination["namespace"] = namespace
+		}
+		return Resource{
+			"project":     "platform",
+			"source":      Resource{"repoURL": "https://git.example.com/infra.git", "targetRevision": "main", "path": path},
+			"destination": destination,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		output  string
+		opts    ArgoCDOptions
+		want    []Resource
+		wantErr error
+	}{
+		{
+			name: "application per group",
+			opts: ArgoCDOptions{RepoURL: "https://git.example.com/infra.git", TargetRevision: "main", Project: "platform"},
+			want: []Resource{
+				{
+					"apiVersion": argoCDAPIVersion,
+					"kind":       ArgoCDApplication,
+					"metadata":   Resource{"name": "prod-crds", "namespace": "argocd", "annotations": Resource{argoCDSyncWave: "0"}},
+					"spec":       spec("deploy/prod/crds", ""),
+				},
+				{
+					"apiVersion": argoCDAPIVersion,
+					"kind":       ArgoCDApplication,
+					"metadata":   Resource{"name": "prod", "namespace": "argocd", "annotations": Resource{argoCDSyncWave: "2"}},
+					"spec":       spec("deploy/prod", "app"),
+				},
+			},
+		},
+		{
+			name:   "application set",
+			output: "/tmp/prod",
+			opts:   ArgoCDOptions{Kind: "applicationset", Name: "web", Path: "clusters/prod", RepoURL: "https://git.example.com/infra.git", TargetRevision: "main", Project: "platform"},
+			want: []Resource{
+				{
+					"apiVersion": argoCDAPIVersion,
+					"kind":       ArgoCDApplicationSet,
+					"metadata":   Resource{"name": "web", "namespace": "argocd"},
+					"spec": Resource{
+						"generators": []any{
+							Resource{"list": Resource{"elements": []map[string]string{
+								{"name": "web-crds", "path": "clusters/prod/crds", "namespace": ""},
+								{"name": "web", "path": "clusters/prod", "namespace": "app"},
+							}}},
+						},
+						"template": Resource{
+							"metadata": Resource{"name": "{{name}}"},
+							"spec":     spec("{{path}}", "{{namespace}}"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "missing repo url",
+			opts:    ArgoCDOptions{},
+			wantErr: ErrMissingRepoURL,
+		},
+		{
+			name:    "absolute output path",
+			output:  "/tmp/prod",
+			opts:    ArgoCDOptions{RepoURL: "https://git.example.com/infra.git"},
+			wantErr: ErrMissingArgoCDPath,
+		},
+		{
+			name:    "output path outside the working directory",
+			output:  "../prod",
+			opts:    ArgoCDOptions{RepoURL: "https://git.example.com/infra.git"},
+			wantErr: ErrMissingArgoCDPath,
+		},
+		{
+			name:    "invalid kind",
+			opts:    ArgoCDOptions{Kind: "AppProject", RepoURL: "https://git.example.com/infra.git"},
+			wantErr: ErrInvalidArgoCDKind,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(WithArgoCD(tt.opts))
+			got, err := p.argoCDApplications(cmp.Or(tt.output, "deploy/prod/"), groups, files)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("argoCDApplications() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("argoCDApplications() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_addKustomizations(t *testing.T) {
+	newFiles := func() map[string][]Resource {
+		return map[string][]Resource{
+			"crds/customresourcedefinition.yaml": {{"kind": "CustomResourceDefinition"}},
+			"service.yaml":                       {{"kind": "Service"}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts []ParserOpt
+		want map[string][]Resource
+	}{
+		{
+			name: "single kustomization",
+			want: map[string][]Resource{
+				"kustomization.yaml": {newKustomizeResource("crds/customresourcedefinition.yaml", "service.yaml")},
+			},
+		},
+		{
+			name: "kustomization per group",
+			opts: []ParserOpt{WithArgoCD(ArgoCDOptions{})},
+			want: map[string][]Resource{
+				"kustomization.yaml":      {newKustomizeResource("service.yaml")},
+				"crds/kustomization.yaml": {newKustomizeResource("customresourcedefinition.yaml")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := newFiles()
+			if err := New(tt.opts...).addKustomizations(files, outputGroups(files), nil); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.want {
+				if !reflect.DeepEqual(files[name], want) {
+					t.Errorf("%s = %v, want %v", name, files[name], want)
+				}
+			}
+			if len(files) != len(newFiles())+len(tt.want) {
+				t.Errorf("expected %d files, got %v", len(newFiles())+len(tt.want), files)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package parser
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	chartTemplatesDir    = "templates"
+	defaultChartVersion  = "0.1.0"
+	chartPlaceholderForm = "__SPLINTER_VALUE_%d__"
+)
+
+var (
+	chartPlaceholderExp = regexp.MustCompile(`__SPLINTER_VALUE_(\d+)__`)
+	replicatedKinds     = []string{"Deployment", "StatefulSet", "ReplicaSet"}
+)
+
+// ChartOptions describes the chart written by Chart. Empty fields fall back to defaults.
+type ChartOptions struct {
+	// Name defaults to the base of the output directory
+	Name        string
+	Description string
+	// Version defaults to 0.1.0
+	Version    string
+	AppVersion string
+}
+
+// chartMetadata is the contents of Chart.yaml
+type chartMetadata struct {
+	APIVersion  string `yaml:"apiVersion"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Type        string `yaml:"type"`
+	Version     string `yaml:"version"`
+	AppVersion  string `yaml:"appVersion,omitempty"`
+}
+
+// chartValues collects the values of a chart and the template expressions that replace them in the manifests
+type chartValues struct {
+	values      Resource
+	expressions []string
+	keys        map[string]bool
+}
+
+// Chart reads the input and writes a Helm chart to outputPath. CustomResourceDefinitions go into crds/ and every other
+// resource into templates/, laid out as split would. Images, replica counts and resource requests of workloads are
+// moved into values.yaml and referenced from the templates.
+func (p *Parser) Chart(inputFiles []string, stdin io.Reader, outputPath string, opts ChartOptions) error {
+	read, err := p.read(inputFiles, stdin)
+	if err != nil {
+		return err
+	}
+
+	crds := make([]Resource, 0)
+	resources := make([]Resource, 0, len(read))
+	for _, r := range read {
+		switch kind, _ := r.Kind(); {
+		case strings.EqualFold(kind, "kustomization"):
+		case kind == "CustomResourceDefinition":
+			crds = append(crds, r)
+		default:
+			resources = append(resources, r)
+		}
+	}
+
+	values := &chartValues{values: make(Resource), keys: make(map[string]bool)}
+	for _, r := range resources {
+		values.extract(r)
+	}
+
+	for name, rs := range p.groupFiles(resources) {
+		var buf bytes.Buffer
+		if err := write(&buf, p.indentSize, rs...); err != nil {
+			return err
+		}
+
+		if err := p.writeFile(path.Join(outputPath, chartTemplatesDir, name), values.template(buf.Bytes())); err != nil {
+			return err
+		}
+	}
+
+	if len(crds) > 0 {
+		if err := p.write(path.Join(outputPath, crdsDir, "customresourcedefinition.yaml"), p.indentSize, crds...); err != nil {
+			return err
+		}
+	}
+
+	if err := p.write(path.Join(outputPath, "values.yaml"), p.indentSize, values.values); err != nil {
+		return err
+	}
+
+	metadata := chartMetadata{
+		APIVersion:  "v2",
+		Name:        cmp.Or(opts.Name, path.Base(path.Clean(outputPath))),
+		Description: opts.Description,
+		Type:        "application",
+		Version:     cmp.Or(opts.Version, defaultChartVersion),
+		AppVersion:  opts.AppVersion,
+	}
+
+	var buf bytes.Buffer
+	e := yaml.NewEncoder(&buf)
+	e.SetIndent(p.indentSize)
+	if err := e.Encode(metadata); err != nil {
+		return err
+	}
+	if err := e.Close(); err != nil {
+		return err
+	}
+
+	return p.writeFile(path.Join(outputPath, "Chart.yaml"), buf.Bytes())
+}
+
+// extract moves the images, replica count and resource requests of a workload into the values
+func (c *chartValues) extract(r Resource) {
+	kind, _ := r.Kind()
+	containers := r.containers()
+	replicated := slices.Contains(replicatedKinds, kind)
+	if len(containers) == 0 && !replicated {
+		return
+	}
+
+	key := c.workloadKey(r)
+	values := make(Resource)
+
+	if replicated {
+		spec, _ := nestedMap(r, "spec")
+		replicas, ok := spec["replicas"]
+		if !ok {
+			replicas = 1
+		}
+		values["replicaCount"] = replicas
+		spec["replicas"] = c.placeholder(fmt.Sprintf("{{ .Values.%s.replicaCount }}", key))
+	}
+
+	containerValues := make(Resource)
+	for _, container := range containers {
+		name := valuesKey(containerName(container))
+		if name == "" || containerValues[name] != nil {
+			continue
+		}
+
+		ref := fmt.Sprintf(".Values.%s.containers.%s", key, name)
+		cv := make(Resource)
+
+		if image, ok := container["image"].(string); ok && image != "" {
+			repository, tag, digest := splitImage(image)
+			iv := Resource{"repository": repository}
+			expr := fmt.Sprintf("{{ %s.image.repository }}", ref)
+			if tag != "" {
+				iv["tag"] = tag
+				expr += fmt.Sprintf(":{{ %s.image.tag }}", ref)
+			}
+			if digest != "" {
+				iv["digest"] = digest
+				expr += fmt.Sprintf("@{{ %s.image.digest }}", ref)
+			}
+			cv["image"] = iv
+			container["image"] = c.placeholder(strconv.Quote(expr))
+		}
+
+		if requests, ok := nestedMap(container, "resources", "requests"); ok && len(requests) > 0 {
+			rv := make(Resource, len(requests))
+			for k, v := range requests {
+				rv[k] = v
+				requests[k] = c.placeholder(fmt.Sprintf("{{ index %s.resources.requests %q | quote }}", ref, k))
+			}
+			cv["resources"] = Resource{"requests": rv}
+		}
+
+		if len(cv) > 0 {
+			containerValues[name] = cv
+		}
+	}
+
+	if len(containerValues) > 0 {
+		values["containers"] = containerValues
+	}
+
+	c.values[key] = values
+}
+
+// workloadKey returns the values key of a workload, falling back to including the kind when two workloads share a name
+func (c *chartValues) workloadKey(r Resource) string {
+	kind, _ := r.Kind()
+	key := valuesKey(r.Name())
+	if key == "" || c.keys[key] {
+		key = valuesKey(r.Name() + "-" + kind)
+	}
+	for i := 2; c.keys[key]; i++ {
+		key = valuesKey(fmt.Sprintf("%s-%s-%d", r.Name(), kind, i))
+	}
+
+	c.keys[key] = true
+	return key
+}
+
+// placeholder records a template expression and returns the plain scalar standing in for it until the manifests are encoded
+func (c *chartValues) placeholder(expr string) string {
+	c.expressions = append(c.expressions, expr)
+	return fmt.Sprintf(chartPlaceholderForm, len(c.expressions)-1)
+}
+
+// template escapes template delimiters already present in the manifests and swaps the placeholders for their expressions
+func (c *chartValues) template(manifest []byte) []byte {
+	escaped := bytes.ReplaceAll(manifest, []byte("{{"), []byte("{{`{{`}}"))
+	return chartPlaceholderExp.ReplaceAllFunc(escaped, func(m []byte) []byte {
+		i, err := strconv.Atoi(string(chartPlaceholderExp.FindSubmatch(m)[1]))
+		if err != nil || i >= len(c.expressions) {
+			return m
+		}
+		return []byte(c.expressions[i])
+	})
+}
+
+// valuesKey turns a kubernetes name into a camel case key usable in template field chains
+func valuesKey(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = b.Len() > 0
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	key := b.String()
+	if key != "" && unicode.IsDigit(rune(key[0])) {
+		key = "_" + key
+	}
+	return key
+}
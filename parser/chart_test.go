@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestChartValues(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-app
+spec:
+  replicas: 3
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: busybox
+      containers:
+        - name: nginx
+          image: registry.local/nginx:1.25
+          resources:
+            requests:
+              cpu: 100m
+              ephemeral-storage: 1Gi
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: alerts
+data:
+  rule: "{{ $labels.instance }} down"
+`
+	resources := readResource(strings.NewReader(input))
+	want := readResource(strings.NewReader(input))
+
+	values := &chartValues{values: make(Resource), keys: make(map[string]bool)}
+	for _, r := range resources {
+		values.extract(r)
+	}
+
+	wantValues := Resource{
+		"webApp": Resource{
+			"replicaCount": 3,
+			"containers": Resource{
+				"init":  Resource{"image": Resource{"repository": "busybox"}},
+				"nginx": Resource{"image": Resource{"repository": "registry.local/nginx", "tag": "1.25"}, "resources": Resource{"requests": Resource{"cpu": "100m", "ephemeral-storage": "1Gi"}}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(values.values, wantValues) {
+		t.Errorf("values = %v, want %v", values.values, wantValues)
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, defaultIndentSize, resources...); err != nil {
+		t.Fatal(err)
+	}
+	manifest := values.template(buf.Bytes())
+
+	// render the templates the way helm would, with the values written to values.yaml
+	tmpl, err := template.New("chart").Funcs(template.FuncMap{"quote": func(v any) string { return strconv.Quote(yamlString(v)) }}).Parse(string(manifest))
+	if err != nil {
+		t.Fatalf("parsing templates: %v\n%s", err, manifest)
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, map[string]any{"Values": map[string]any(roundTrip(t, values.values))}); err != nil {
+		t.Fatalf("rendering templates: %v", err)
+	}
+
+	if got := readResource(&rendered); !reflect.DeepEqual(got, want) {
+		t.Errorf("rendered chart = %v, want %v", got, want)
+	}
+}
+
+func TestValuesKey(t *testing.T) {
+	tests := map[string]string{
+		"web":          "web",
+		"web-app":      "webApp",
+		"my.app-v2":    "myAppV2",
+		"1password":    "_1password",
+		"-leading-sep": "leadingSep",
+	}
+
+	for name, want := range tests {
+		if got := valuesKey(name); got != want {
+			t.Errorf("valuesKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func yamlString(v any) string {
+	b, _ := yaml.Marshal(v)
+	return strings.TrimSpace(string(b))
+}
+
+// roundTrip decodes the values as helm would after reading values.yaml
+func roundTrip(t *testing.T, values Resource) map[string]any {
+	t.Helper()
+	b, err := yaml.Marshal(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
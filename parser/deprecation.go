@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidKubernetesVersion = errors.New("kubernetes version must be in the form 1.29")
+)
+
+const (
+	StatusDeprecated = "deprecated"
+	StatusRemoved    = "removed"
+)
+
+// apiDeprecation describes an apiVersion of a kind that was deprecated and later removed
+type apiDeprecation struct {
+	apiVersion  string
+	kind        string
+	deprecated  string
+	removed     string
+	replacement string
+	// migrate reports whether a resource can be moved to the replacement by only changing its apiVersion
+	migrate func(r Resource) bool
+}
+
+func always(Resource) bool { return true }
+
+// hasSelector reports whether a workload sets spec.selector, which apps/v1 requires and no longer defaults
+func hasSelector(r Resource) bool {
+	spec, ok := nestedMap(r, "spec")
+	return ok && spec["selector"] != nil
+}
+
+// apiDeprecations is based on the kubernetes deprecated API migration guide
+var apiDeprecations = []apiDeprecation{
+	{apiVersion: "extensions/v1beta1", kind: "Deployment", deprecated: "1.9", removed: "1.16", replacement: "apps/v1", migrate: hasSelector},
+	{apiVersion: "extensions/v1beta1", kind: "DaemonSet", deprecated: "1.9", removed: "1.16", replacement: "apps/v1", migrate: hasSelector},
+	{apiVersion: "extensions/v1beta1", kind: "ReplicaSet", deprecated: "1.9", removed: "1.16", replacement: "apps/v1", migrate: hasSelector},
+	{apiVersion: "extensions/v1beta1", kind: "NetworkPolicy", deprecated: "1.9", removed: "1.16", replacement: "networking.k8s.io/v1", migrate: always},
+	{apiVersion: "extensions/v1beta1", kind: "PodSecurityPolicy", deprecated: "1.10", removed: "1.16", replacement: "policy/v1beta1"},
+	{apiVersion: "extensions/v1beta1", kind: "Ingress", deprecated: "1.14", removed: "1.22", replacement: "networking.k8s.io/v1"},
+	{apiVersion: "apps/v1beta1", kind: "Deployment", deprecated: "1.9", removed: "1.16", replacement: "apps/v1", migrate: hasSelector},
+	{apiVersion: "apps/v1beta1", kind: "StatefulSet", deprecated: "1.9", removed: "1.16", replacement: "apps/v1", migrate: hasSelector},
+	{apiVersion: "apps/v1beta2", kind: "Deployment", deprecated: "1.9", removed: "1.16", replacement: "apps/v1", migrate: hasSelector},
+	{apiVersion: "apps/v1beta2", kind: "StatefulSet", deprecated: "1.9", removed: "1.16", replacement: "apps/v1", migrate: hasSelector},
+	{apiVersion: "apps/v1beta2", kind: "DaemonSet", deprecated: "1.9", removed: "1.16", replacement: "apps/v1", migrate: hasSelector},
+	{apiVersion: "apps/v1beta2", kind: "ReplicaSet", deprecated: "1.9", removed: "1.16", replacement: "apps/v1", migrate: hasSelector},
+	{apiVersion: "networking.k8s.io/v1beta1", kind: "Ingress", deprecated: "1.19", removed: "1.22", replacement: "networking.k8s.io/v1"},
+	{apiVersion: "networking.k8s.io/v1beta1", kind: "IngressClass", deprecated: "1.19", removed: "1.22", replacement: "networking.k8s.io/v1", migrate: always},
+	{apiVersion: "rbac.authorization.k8s.io/v1beta1", kind: "ClusterRole", deprecated: "1.17", removed: "1.22", replacement: "rbac.authorization.k8s.io/v1", migrate: always},
+	{apiVersion: "rbac.authorization.k8s.io/v1beta1", kind: "ClusterRoleBinding", deprecated: "1.17", removed: "1.22", replacement: "rbac.authorization.k8s.io/v1", migrate: always},
+	{apiVersion: "rbac.authorization.k8s.io/v1beta1", kind: "Role", deprecated: "1.17", removed: "1.22", replacement: "rbac.authorization.k8s.io/v1", migrate: always},
+	{apiVersion: "rbac.authorization.k8s.io/v1beta1", kind: "RoleBinding", deprecated: "1.17", removed: "1.22", replacement: "rbac.authorization.k8s.io/v1", migrate: always},
+	{apiVersion: "scheduling.k8s.io/v1beta1", kind: "PriorityClass", deprecated: "1.14", removed: "1.22", replacement: "scheduling.k8s.io/v1", migrate: always},
+	{apiVersion: "storage.k8s.io/v1beta1", kind: "CSIDriver", deprecated: "1.19", removed: "1.22", replacement: "storage.k8s.io/v1", migrate: always},
+	{apiVersion: "storage.k8s.io/v1beta1", kind: "CSINode", deprecated: "1.17", removed: "1.22", replacement: "storage.k8s.io/v1", migrate: always},
+	{apiVersion: "storage.k8s.io/v1beta1", kind: "StorageClass", deprecated: "1.6", removed: "1.22", replacement: "storage.k8s.io/v1", migrate: always},
+	{apiVersion: "storage.k8s.io/v1beta1", kind: "VolumeAttachment", deprecated: "1.13", removed: "1.22", replacement: "storage.k8s.io/v1", migrate: always},
+	{apiVersion: "storage.k8s.io/v1beta1", kind: "CSIStorageCapacity", deprecated: "1.24", removed: "1.27", replacement: "storage.k8s.io/v1", migrate: always},
+	{apiVersion: "apiextensions.k8s.io/v1beta1", kind: "CustomResourceDefinition", deprecated: "1.16", removed: "1.22", replacement: "apiextensions.k8s.io/v1"},
+	{apiVersion: "apiregistration.k8s.io/v1beta1", kind: "APIService", deprecated: "1.19", removed: "1.22", replacement: "apiregistration.k8s.io/v1", migrate: always},
+	{apiVersion: "admissionregistration.k8s.io/v1beta1", kind: "MutatingWebhookConfiguration", deprecated: "1.16", removed: "1.22", replacement: "admissionregistration.k8s.io/v1"},
+	{apiVersion: "admissionregistration.k8s.io/v1beta1", kind: "ValidatingWebhookConfiguration", deprecated: "1.16", removed: "1.22", replacement: "admissionregistration.k8s.io/v1"},
+	{apiVersion: "certificates.k8s.io/v1beta1", kind: "CertificateSigningRequest", deprecated: "1.19", removed: "1.22", replacement: "certificates.k8s.io/v1"},
+	{apiVersion: "coordination.k8s.io/v1beta1", kind: "Lease", deprecated: "1.14", removed: "1.22", replacement: "coordination.k8s.io/v1", migrate: always},
+	{apiVersion: "batch/v1beta1", kind: "CronJob", deprecated: "1.21", removed: "1.25", replacement: "batch/v1", migrate: always},
+	{apiVersion: "discovery.k8s.io/v1beta1", kind: "EndpointSlice", deprecated: "1.21", removed: "1.25", replacement: "discovery.k8s.io/v1"},
+	{apiVersion: "events.k8s.io/v1beta1", kind: "Event", deprecated: "1.19", removed: "1.25", replacement: "events.k8s.io/v1"},
+	{apiVersion: "autoscaling/v2beta1", kind: "HorizontalPodAutoscaler", deprecated: "1.22", removed: "1.25", replacement: "autoscaling/v2"},
+	{apiVersion: "autoscaling/v2beta2", kind: "HorizontalPodAutoscaler", deprecated: "1.23", removed: "1.26", replacement: "autoscaling/v2", migrate: always},
+	{apiVersion: "policy/v1beta1", kind: "PodDisruptionBudget", deprecated: "1.21", removed: "1.25", replacement: "policy/v1"},
+	{apiVersion: "policy/v1beta1", kind: "PodSecurityPolicy", deprecated: "1.21", removed: "1.25"},
+	{apiVersion: "node.k8s.io/v1beta1", kind: "RuntimeClass", deprecated: "1.20", removed: "1.25", replacement: "node.k8s.io/v1", migrate: always},
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta1", kind: "FlowSchema", deprecated: "1.23", removed: "1.26", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta1", kind: "PriorityLevelConfiguration", deprecated: "1.23", removed: "1.26", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta2", kind: "FlowSchema", deprecated: "1.26", removed: "1.29", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta2", kind: "PriorityLevelConfiguration", deprecated: "1.26", removed: "1.29", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta3", kind: "FlowSchema", deprecated: "1.29", removed: "1.32", replacement: "flowcontrol.apiserver.k8s.io/v1", migrate: always},
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta3", kind: "PriorityLevelConfiguration", deprecated: "1.29", removed: "1.32", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// Deprecation is a resource using an apiVersion that is deprecated or removed in the target kubernetes version
+type Deprecation struct {
+	Source       string `json:"source"`
+	Line         int    `json:"line"`
+	Resource     string `json:"resource"`
+	APIVersion   string `json:"apiVersion"`
+	Kind         string `json:"kind"`
+	Status       string `json:"status"`
+	DeprecatedIn string `json:"deprecatedIn"`
+	RemovedIn    string `json:"removedIn"`
+	Replacement  string `json:"replacement,omitempty"`
+}
+
+func (d Deprecation) String() string {
+	var version string
+	switch d.Status {
+	case StatusRemoved:
+		version = d.RemovedIn
+	default:
+		version = d.DeprecatedIn
+	}
+
+	s := fmt.Sprintf("%s:%d %s: %s %s %s in %s", d.Source, d.Line, d.Resource, d.APIVersion, d.Kind, d.Status, version)
+	if d.Replacement != "" {
+		s += ", use " + d.Replacement
+	}
+	return s
+}
+
+// Deprecations reads the input and returns every resource using an apiVersion deprecated or removed in the target version
+func (p *Parser) Deprecations(inputFiles []string, stdin io.Reader, target string) ([]Deprecation, error) {
+	version, err := parseKubernetesVersion(target)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := p.readDocuments(inputFiles, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	deprecations := make([]Deprecation, 0)
+	for _, d := range docs {
+		dep, status, ok := findDeprecation(d.resource, version)
+		if !ok {
+			continue
+		}
+
+		deprecations = append(deprecations, Deprecation{
+			Source:       d.source,
+			Line:         d.line(),
+			Resource:     d.resource.Ref(),
+			APIVersion:   dep.apiVersion,
+			Kind:         dep.kind,
+			Status:       status,
+			DeprecatedIn: dep.deprecated,
+			RemovedIn:    dep.removed,
+			Replacement:  dep.replacement,
+		})
+	}
+
+	return deprecations, nil
+}
+
+// MigrateAPIsTransform returns a transform moving resources off apiVersions deprecated or removed in the target
+// version when only the apiVersion has to change. Resources that need manual changes are left untouched.
+func MigrateAPIsTransform(target string) (Transform, error) {
+	version, err := parseKubernetesVersion(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(resources []Resource) ([]Resource, error) {
+		for _, r := range resources {
+			dep, _, ok := findDeprecation(r, version)
+			if !ok || dep.migrate == nil || dep.replacement == "" || !dep.migrate(r) {
+				continue
+			}
+			r["apiVersion"] = dep.replacement
+		}
+
+		return resources, nil
+	}, nil
+}
+
+func findDeprecation(r Resource, version [2]int) (apiDeprecation, string, bool) {
+	kind, err := r.Kind()
+	if err != nil {
+		return apiDeprecation{}, "", false
+	}
+	apiVersion, _ := r["apiVersion"].(string)
+
+	for _, dep := range apiDeprecations {
+		if dep.apiVersion != apiVersion || dep.kind != kind {
+			continue
+		}
+
+		removed, _ := parseKubernetesVersion(dep.removed)
+		deprecated, _ := parseKubernetesVersion(dep.deprecated)
+		switch {
+		case compareVersions(version, removed) >= 0:
+			return dep, StatusRemoved, true
+		case compareVersions(version, deprecated) >= 0:
+			return dep, StatusDeprecated, true
+		}
+	}
+
+	return apiDeprecation{}, "", false
+}
+
+// parseKubernetesVersion parses a minor kubernetes version such as 1.29 or v1.29.3
+func parseKubernetesVersion(s string) ([2]int, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) < 2 {
+		return [2]int{}, fmt.Errorf("%w: %q", ErrInvalidKubernetesVersion, s)
+	}
+
+	var v [2]int
+	for i := range v {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return [2]int{}, fmt.Errorf("%w: %q", ErrInvalidKubernetesVersion, s)
+		}
+		v[i] = n
+	}
+
+	return v, nil
+}
+
+func compareVersions(a, b [2]int) int {
+	if a[0] != b[0] {
+		return a[0] - b[0]
+	}
+	return a[1] - b[1]
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kdwils/splinter/pkg/fio/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParser_Deprecations(t *testing.T) {
+	input := `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+---
+apiVersion: flowcontrol.apiserver.k8s.io/v1beta3
+kind: FlowSchema
+metadata:
+  name: fs
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`
+
+	tests := []struct {
+		name    string
+		target  string
+		want    []Deprecation
+		wantErr bool
+	}{
+		{
+			name:   "removed and deprecated",
+			target: "1.29",
+			want: []Deprecation{
+				{Source: "input.yaml", Line: 1, Resource: "Ingress/web", APIVersion: "extensions/v1beta1", Kind: "Ingress", Status: StatusRemoved, DeprecatedIn: "1.14", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+				{Source: "input.yaml", Line: 6, Resource: "FlowSchema/fs", APIVersion: "flowcontrol.apiserver.k8s.io/v1beta3", Kind: "FlowSchema", Status: StatusDeprecated, DeprecatedIn: "1.29", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+			},
+		},
+		{
+			name:   "older target",
+			target: "v1.20.4",
+			want: []Deprecation{
+				{Source: "input.yaml", Line: 1, Resource: "Ingress/web", APIVersion: "extensions/v1beta1", Kind: "Ingress", Status: StatusDeprecated, DeprecatedIn: "1.14", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+			},
+		},
+		{
+			name:    "invalid target",
+			target:  "latest",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockFio := mocks.NewMockFileIO(ctrl)
+			mockFio.EXPECT().ReadFile("input.yaml").Return([]byte(input), nil).MaxTimes(1)
+
+			got, err := New(WithFileIO(mockFio)).Deprecations([]string{"input.yaml"}, nil, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Deprecations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Deprecations() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateAPIsTransform(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource Resource
+		want     string
+	}{
+		{
+			name:     "simple rename",
+			resource: Resource{"apiVersion": "batch/v1beta1", "kind": "CronJob"},
+			want:     "batch/v1",
+		},
+		{
+			name:     "workload with selector",
+			resource: Resource{"apiVersion": "extensions/v1beta1", "kind": "Deployment", "spec": Resource{"selector": Resource{}}},
+			want:     "apps/v1",
+		},
+		{
+			name:     "workload without selector is left alone",
+			resource: Resource{"apiVersion": "extensions/v1beta1", "kind": "Deployment", "spec": Resource{}},
+			want:     "extensions/v1beta1",
+		},
+		{
+			name:     "ingress needs manual changes",
+			resource: Resource{"apiVersion": "networking.k8s.io/v1beta1", "kind": "Ingress"},
+			want:     "networking.k8s.io/v1beta1",
+		},
+		{
+			name:     "not yet deprecated in target",
+			resource: Resource{"apiVersion": "flowcontrol.apiserver.k8s.io/v1beta3", "kind": "FlowSchema"},
+			want:     "flowcontrol.apiserver.k8s.io/v1beta3",
+		},
+	}
+
+	migrate, err := MigrateAPIsTransform("1.28")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := migrate([]Resource{tt.resource})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got[0]["apiVersion"] != tt.want {
+				t.Errorf("apiVersion = %v, want %v", got[0]["apiVersion"], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package parser
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+
+	diffContext = 3
+)
+
+// ResourceDiff is a resource that was added, removed or changed between two sets of manifests
+type ResourceDiff struct {
+	Status   string `json:"status"`
+	Resource string `json:"resource"`
+	// From and To are the resource rendered as yaml before and after, empty when it was added or removed
+	From string `json:"-"`
+	To   string `json:"-"`
+}
+
+// Unified returns the difference as a unified diff of the yaml of the resource
+func (d ResourceDiff) Unified() string {
+	fromName, toName := "a/"+d.Resource, "b/"+d.Resource
+	if d.Status == DiffAdded {
+		fromName = "/dev/null"
+	}
+	if d.Status == DiffRemoved {
+		toName = "/dev/null"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	b.WriteString(unifiedDiff(splitLines(d.From), splitLines(d.To), diffContext))
+	return b.String()
+}
+
+// Diff reads both inputs and returns every resource that differs between them, matched by api group, kind,
+// namespace and name. Kustomizations are left out, and resources are sorted by kind, namespace and name.
+func (p *Parser) Diff(from, to []string) ([]ResourceDiff, error) {
+	before, err := p.readOverlayInput(from)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := p.readOverlayInput(to)
+	if err != nil {
+		return nil, err
+	}
+
+	beforeKeys, beforeByKey := diffKeys(before)
+	afterKeys, afterByKey := diffKeys(after)
+
+	diffs := make([]ResourceDiff, 0)
+	for _, key := range afterKeys {
+		r := afterByKey[key]
+		old, ok := beforeByKey[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, ResourceDiff{Status: DiffAdded, Resource: r.Ref(), To: p.renderResource(r)})
+		case !reflect.DeepEqual(plainValue(old), plainValue(r)):
+			diffs = append(diffs, ResourceDiff{Status: DiffChanged, Resource: r.Ref(), From: p.renderResource(old), To: p.renderResource(r)})
+		}
+	}
+	for _, key := range beforeKeys {
+		if _, ok := afterByKey[key]; !ok {
+			r := beforeByKey[key]
+			diffs = append(diffs, ResourceDiff{Status: DiffRemoved, Resource: r.Ref(), From: p.renderResource(r)})
+		}
+	}
+
+	slices.SortStableFunc(diffs, func(a, b ResourceDiff) int {
+		return cmp.Compare(a.Resource, b.Resource)
+	})
+	return diffs, nil
+}
+
+// diffKeys keys resources by overlayKey, numbering repeated keys so duplicates are compared in order
+func diffKeys(resources []Resource) ([]string, map[string]Resource) {
+	keys := make([]string, 0, len(resources))
+	byKey := make(map[string]Resource, len(resources))
+	seen := make(map[string]int)
+	for _, r := range resources {
+		key := overlayKey(r)
+		if n := seen[key]; n > 0 {
+			seen[key]++
+			key = fmt.Sprintf("%s#%d", key, n)
+		} else {
+			seen[key] = 1
+		}
+		keys = append(keys, key)
+		byKey[key] = r
+	}
+	return keys, byKey
+}
+
+func (p *Parser) renderResource(r Resource) string {
+	var buf bytes.Buffer
+	if err := write(&buf, p.indentSize, r); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffOp is a line kept, removed or added by a diff
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// lineDiff returns the operations turning a into b along a shortest edit script. Between kept lines, removals come
+// before additions.
+func lineDiff(a, b []string) []diffOp {
+	d := lineDiffer{a: a, b: b, keptA: make([]bool, len(a)), keptB: make([]bool, len(b))}
+	d.compare(0, len(a), 0, len(b))
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && !d.keptA[i]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		case j < len(b) && !d.keptB[j]:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		default:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		}
+	}
+	return ops
+}
+
+// lineDiffer marks the lines of a and b kept by a shortest edit script, found with Myers' algorithm in linear space
+// by recursively splitting both sides at the middle of the edit script
+type lineDiffer struct {
+	a, b         []string
+	keptA, keptB []bool
+}
+
+func (d *lineDiffer) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.keptA[aLo], d.keptB[bLo] = true, true
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+		d.keptA[aHi], d.keptB[bHi] = true, true
+	}
+	if aLo == aHi || bLo == bHi {
+		return
+	}
+
+	x, y, ok := d.middle(aLo, aHi, bLo, bHi)
+	if !ok {
+		return
+	}
+	d.compare(aLo, x, bLo, y)
+	d.compare(x, aHi, y, bHi)
+}
+
+// middle returns the point where the edit scripts searched from both ends of a[aLo:aHi] and b[bLo:bHi] meet, or false
+// when the two have no line in common
+func (d *lineDiffer) middle(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	odd := delta%2 != 0
+	// the diagonals running off either side are skipped once reached
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[i] = x
+
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if j := offset + delta - k; j >= 0 && j < len(backward) && backward[j] != -1 && x >= n-backward[j] {
+					return aLo + x, bLo + y, true
+				}
+			}
+		}
+
+		for k := -step + bStart; k <= step-bEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if j := offset + delta - k; j >= 0 && j < len(forward) && forward[j] != -1 {
+					fx := forward[j]
+					if fx >= n-x {
+						return aLo + fx, bLo + offset + fx - j, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// unifiedDiff renders the hunks of a line diff with context lines around every change
+func unifiedDiff(a, b []string, context int) string {
+	ops := lineDiff(a, b)
+
+	changes := make([]int, 0)
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	var out strings.Builder
+	for c := 0; c < len(changes); {
+		start := max(changes[c]-context, 0)
+		end := changes[c] + 1
+		for c < len(changes) && changes[c] <= end+2*context {
+			end = changes[c] + 1
+			c++
+		}
+		end = min(end+context, len(ops))
+
+		fromLine, toLine := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				fromLine++
+			}
+			if op.kind != '-' {
+				toLine++
+			}
+		}
+		fromCount, toCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				fromCount++
+			}
+			if op.kind != '-' {
+				toCount++
+			}
+		}
+		if fromCount == 0 {
+			fromLine--
+		}
+		if toCount == 0 {
+			toLine--
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+	}
+
+	return out.String()
+}
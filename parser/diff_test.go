@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/kdwils/splinter/pkg/fio"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "b"},
+			want: "",
+		},
+		{
+			name: "added",
+			a:    nil,
+			b:    []string{"a", "b"},
+			want: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "changed line with context",
+			a:    []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"},
+			b:    []string{"1", "2", "3", "4", "x", "6", "7", "8", "9"},
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			a:    []string{"a", "1", "2", "3", "4", "5", "6", "7", "b"},
+			b:    []string{"A", "1", "2", "3", "4", "5", "6", "7", "B"},
+			want: "@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name: "close changes share a hunk",
+			a:    []string{"a", "1", "2", "3", "4", "5", "6", "b"},
+			b:    []string{"A", "1", "2", "3", "4", "5", "6", "B"},
+			want: "@@ -1,8 +1,8 @@\n-a\n+A\n 1\n 2\n 3\n 4\n 5\n 6\n-b\n+B\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff(tt.a, tt.b, diffContext); got != tt.want {
+				t.Errorf("unifiedDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	// lcsLength is the number of lines a shortest edit script keeps
+	lcsLength := func(a, b []string) int {
+		prev := make([]int, len(b)+1)
+		for i := range a {
+			cur := make([]int, len(b)+1)
+			for j := range b {
+				if a[i] == b[j] {
+					cur[j+1] = prev[j] + 1
+				} else {
+					cur[j+1] = max(prev[j+1], cur[j])
+				}
+			}
+			prev = cur
+		}
+		return prev[len(b)]
+	}
+
+	r := rand.New(rand.NewSource(1))
+	lines := func() []string {
+		l := make([]string, r.Intn(30))
+		for i := range l {
+			l[i] = string(rune('a' + r.Intn(4)))
+		}
+		return l
+	}
+
+	for n := 0; n < 500; n++ {
+		a, b := lines(), lines()
+		ops := lineDiff(a, b)
+
+		var gotA, gotB []string
+		kept := 0
+		for i, op := range ops {
+			if op.kind != '+' {
+				gotA = append(gotA, op.line)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.line)
+			}
+			if op.kind == ' ' {
+				kept++
+			}
+			if op.kind == '-' && i > 0 && ops[i-1].kind == '+' {
+				t.Fatalf("lineDiff(%q, %q) adds before removing: %v", a, b, ops)
+			}
+		}
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Fatalf("lineDiff(%q, %q) = %v does not turn a into b", a, b, ops)
+		}
+		if want := lcsLength(a, b); kept != want {
+			t.Fatalf("lineDiff(%q, %q) keeps %d lines, want %d", a, b, kept, want)
+		}
+	}
+
+	t.Run("large input", func(t *testing.T) {
+		a := make([]string, 20000)
+		for i := range a {
+			a[i] = fmt.Sprintf("line %d", i)
+		}
+		b := slices.Clone(a)
+		for i := 0; i < len(b); i += 1000 {
+			b[i] = "changed"
+		}
+
+		changed := 0
+		for _, op := range lineDiff(a, b) {
+			if op.kind == '+' {
+				changed++
+			}
+		}
+		if changed != 20 {
+			t.Errorf("lineDiff() added %d lines, want 20", changed)
+		}
+	})
+}
+
+func TestParser_Diff(t *testing.T) {
+	m := fio.NewMemory()
+	m.AddFile("from/deployment.yaml", []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`))
+	m.AddFile("from/configmap.yaml", []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`))
+	m.AddFile("from/kustomization.yaml", []byte(`kind: Kustomization
+resources:
+  - deployment.yaml
+`))
+	m.AddFile("to/deployment.yaml", []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+`))
+	m.AddFile("to/service.yaml", []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+
+	diffs, err := New(WithFileIO(m)).Diff([]string{"from"}, []string{"to"})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	got := make([]ResourceDiff, 0, len(diffs))
+	for _, d := range diffs {
+		got = append(got, ResourceDiff{Status: d.Status, Resource: d.Resource})
+	}
+	want := []ResourceDiff{
+		{Status: DiffRemoved, Resource: "ConfigMap/settings"},
+		{Status: DiffChanged, Resource: "Deployment/web"},
+		{Status: DiffAdded, Resource: "Service/web"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff() = %v, want %v", got, want)
+	}
+
+	wantUnified := `--- a/Deployment/web
++++ b/Deployment/web
+@@ -3,4 +3,4 @@
+ metadata:
+   name: web
+ spec:
+-  replicas: 1
++  replicas: 2
+`
+	if got := diffs[1].Unified(); got != wantUnified {
+		t.Errorf("Unified() = %q, want %q", got, wantUnified)
+	}
+}
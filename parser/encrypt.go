@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kdwils/splinter/pkg/sops"
+)
+
+// WithDecryption decrypts sops encrypted documents with the decrypter's age identities as they are read
+func WithDecryption(d *sops.Decrypter) ParserOpt {
+	return func(p *Parser) {
+		p.decrypter = d
+	}
+}
+
+// EncryptSecretsTransform returns a transform encrypting the data and stringData values of every Secret
+// in the sops layout. It should run after every other transform so nothing rewrites the encrypted values.
+func EncryptSecretsTransform(e *sops.Encrypter) Transform {
+	return func(resources []Resource) ([]Resource, error) {
+		for i, r := range resources {
+			if !isSecret(r) || isEncrypted(r) {
+				continue
+			}
+
+			// encode to a node first so the values are hashed in the order they are written
+			var node yaml.Node
+			if err := node.Encode(r); err != nil {
+				return nil, err
+			}
+			if err := e.Encrypt(&node); err != nil {
+				return nil, fmt.Errorf("encrypting %s: %w", r.Ref(), err)
+			}
+
+			encrypted := make(Resource)
+			if err := node.Decode(&encrypted); err != nil {
+				return nil, err
+			}
+			resources[i] = encrypted
+		}
+
+		return resources, nil
+	}
+}
+
+func isEncrypted(r Resource) bool {
+	_, ok := r[sops.MetadataKey]
+	return ok
+}
+
+// decrypt decrypts every encrypted document in place
+func (p *Parser) decrypt(docs []document) error {
+	if p.decrypter == nil {
+		return nil
+	}
+
+	for i, d := range docs {
+		if !sops.IsEncrypted(d.node) {
+			continue
+		}
+
+		if err := p.decrypter.Decrypt(d.node); err != nil {
+			return fmt.Errorf("decrypting %s document %d: %w", d.source, d.index, err)
+		}
+
+		r := make(Resource)
+		if err := d.node.Decode(&r); err != nil {
+			return err
+		}
+		docs[i].resource = r
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/kdwils/splinter/pkg/fio/mocks"
+	"github.com/kdwils/splinter/pkg/sops"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEncryptSecretsTransform(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := sops.ReadRecipients(strings.NewReader(id.Recipient().String()), sops.SecretRegex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := sops.ReadIdentities(strings.NewReader(id.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := Resource{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   Resource{"name": "creds"},
+		"data":       Resource{"password": "aHVudGVyMg=="},
+	}
+	configMap := Resource{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   Resource{"name": "settings"},
+		"data":       Resource{"user": "admin"},
+	}
+
+	got, err := EncryptSecretsTransform(e)([]Resource{secret, configMap})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !isEncrypted(got[0]) || isPlainSecret(got[0]) {
+		t.Errorf("expected the secret to be encrypted, got %v", got[0])
+	}
+	if data, _ := asMap(got[0]["data"]); !strings.HasPrefix(data["password"].(string), "ENC[AES256_GCM,") {
+		t.Errorf("expected an encrypted password, got %v", data["password"])
+	}
+	if !reflect.DeepEqual(got[1], configMap) {
+		t.Errorf("expected the configmap to be untouched, got %v", got[1])
+	}
+
+	var encrypted bytes.Buffer
+	if err := write(&encrypted, defaultIndentSize, got...); err != nil {
+		t.Fatal(err)
+	}
+
+	var want bytes.Buffer
+	if err := write(&want, defaultIndentSize, secret, configMap); err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockFio := mocks.NewMockFileIO(ctrl)
+	mockFile := mocks.NewMockWriteCloser(ctrl)
+	mockFio.EXPECT().ReadFile("input.yaml").Return(encrypted.Bytes(), nil)
+	mockFio.EXPECT().Stat(".").Return(nil, nil)
+	mockFio.EXPECT().Create("output.yaml").Return(mockFile, nil)
+	var out bytes.Buffer
+	mockFile.EXPECT().Write(gomock.Any()).DoAndReturn(out.Write).AnyTimes()
+	mockFile.EXPECT().Close().Return(nil)
+
+	p := New(WithFileIO(mockFio), WithDecryption(d))
+	if err := p.Merge([]string{"input.yaml"}, nil, "output.yaml"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out.String() != want.String() {
+		t.Errorf("Merge() wrote\n%s\nwant\n%s", out.String(), want.String())
+	}
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"time"
+)
+
+const (
+	fluxDir               = "flux"
+	fluxAPIVersion        = "kustomize.toolkit.fluxcd.io/v1"
+	defaultFluxNamespace  = "flux-system"
+	defaultFluxSourceKind = "GitRepository"
+	defaultFluxSourceName = "flux-system"
+	defaultFluxInterval   = "10m"
+)
+
+var (
+	ErrInvalidFluxInterval = errors.New("invalid flux interval")
+	ErrMissingFluxPath     = errors.New("flux kustomizations require a repository path when the output path is absolute or outside the working directory")
+)
+
+// FluxOptions parameterizes the Flux Kustomizations generated on split. Empty fields fall back to the
+// defaults of a flux bootstrap.
+type FluxOptions struct {
+	// Name prefixes the Kustomization names, defaulting to the base of the output directory
+	Name string
+	// Namespace is the namespace the Kustomizations are created in
+	Namespace string
+	// Path is the output directory relative to the repository root, defaulting to the output path when it is relative
+	// and within the working directory
+	Path            string
+	SourceKind      string
+	SourceName      string
+	SourceNamespace string
+	Interval        string
+	Prune           bool
+	// DependsOn names Kustomizations every generated Kustomization depends on, in addition to the groups applied before it
+	DependsOn []string
+}
+
+// WithFlux writes a Flux Kustomization deploying each group directory of the split output into a flux directory.
+// Every group directory gets its own kustomization, and each group depends on the groups applied before it.
+func WithFlux(opts FluxOptions) ParserOpt {
+	return func(p *Parser) {
+		p.flux = &opts
+	}
+}
+
+// fluxKustomizations returns the Flux Kustomizations for the groups of the split output
+func (p *Parser) fluxKustomizations(outputPath string, groups map[string][]string) ([]Resource, error) {
+	opts := *p.flux
+	if opts.Path == "" && !withinDir(".", outputPath) {
+		return nil, ErrMissingFluxPath
+	}
+	opts.Name = cmp.Or(opts.Name, path.Base(path.Clean(outputPath)))
+	opts.Path = cmp.Or(opts.Path, path.Clean(outputPath))
+	opts.Namespace = cmp.Or(opts.Namespace, defaultFluxNamespace)
+	opts.SourceKind = cmp.Or(opts.SourceKind, defaultFluxSourceKind)
+	opts.SourceName = cmp.Or(opts.SourceName, defaultFluxSourceName)
+	opts.Interval = cmp.Or(opts.Interval, defaultFluxInterval)
+	if _, err := time.ParseDuration(opts.Interval); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFluxInterval, err)
+	}
+
+	sourceRef := Resource{"kind": opts.SourceKind, "name": opts.SourceName}
+	if opts.SourceNamespace != "" {
+		sourceRef["namespace"] = opts.SourceNamespace
+	}
+
+	kustomizations := make([]Resource, 0, len(groups))
+	applied := make([]string, 0, len(groups))
+	for _, group := range sortedGroups(groups) {
+		spec := Resource{
+			"interval":  opts.Interval,
+			"path":      "./" + path.Join(opts.Path, group),
+			"prune":     opts.Prune,
+			"sourceRef": sourceRef,
+		}
+
+		dependsOn := make([]any, 0)
+		for _, name := range slices.Concat(opts.DependsOn, applied) {
+			dependsOn = append(dependsOn, Resource{"name": name})
+		}
+		if len(dependsOn) > 0 {
+			spec["dependsOn"] = dependsOn
+		}
+
+		name := groupName(opts.Name, group)
+		kustomizations = append(kustomizations, Resource{
+			"apiVersion": fluxAPIVersion,
+			"kind":       "Kustomization",
+			"metadata":   Resource{"name": name, "namespace": opts.Namespace},
+			"spec":       spec,
+		})
+		applied = append(applied, name)
+	}
+
+	return kustomizations, nil
+}
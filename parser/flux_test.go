@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"cmp"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParser_fluxKustomizations(t *testing.T) {
+	groups := map[string][]string{
+		"":        {"service.yaml"},
+		"crds":    {"crds/customresourcedefinition.yaml"},
+		"cluster": {"cluster/namespace.yaml"},
+	}
+
+	kustomization := func(name, path string, dependsOn ...string) Resource {
+		spec := Resource{
+			"interval":  "5m",
+			"path":      path,
+			"prune":     true,
+			"sourceRef": Resource{"kind": "GitRepository", "name": "infra", "namespace": "flux-system"},
+		}
+		if len(dependsOn) > 0 {
+			deps := make([]any, 0, len(dependsOn))
+			for _, d := range dependsOn {
+				deps = append(deps, Resource{"name": d})
+			}
+			spec["dependsOn"] = deps
+		}
+		return Resource{
+			"apiVersion": fluxAPIVersion,
+			"kind":       "Kustomization",
+			"metadata":   Resource{"name": name, "namespace": "flux-system"},
+			"spec":       spec,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		output  string
+		opts    FluxOptions
+		want    []Resource
+		wantErr error
+	}{
+		{
+			name: "groups depend on the groups applied before them",
+			opts: FluxOptions{SourceName: "infra", SourceNamespace: "flux-system", Interval: "5m", Prune: true},
+			want: []Resource{
+				kustomization("prod-crds", "./deploy/prod/crds"),
+				kustomization("prod-cluster", "./deploy/prod/cluster", "prod-crds"),
+				kustomization("prod", "./deploy/prod", "prod-crds", "prod-cluster"),
+			},
+		},
+		{
+			name:   "extra dependencies",
+			output: "/tmp/prod",
+			opts:   FluxOptions{Name: "web", Path: "clusters/prod/web", SourceName: "infra", SourceNamespace: "flux-system", Interval: "5m", Prune: true, DependsOn: []string{"infra-controllers"}},
+			want: []Resource{
+				kustomization("web-crds", "./clusters/prod/web/crds", "infra-controllers"),
+				kustomization("web-cluster", "./clusters/prod/web/cluster", "infra-controllers", "web-crds"),
+				kustomization("web", "./clusters/prod/web", "infra-controllers", "web-crds", "web-cluster"),
+			},
+		},
+		{
+			name:    "absolute output path",
+			output:  "/tmp/prod",
+			wantErr: ErrMissingFluxPath,
+		},
+		{
+			name:    "output path outside the working directory",
+			output:  "../prod",
+			wantErr: ErrMissingFluxPath,
+		},
+		{
+			name:    "invalid interval",
+			opts:    FluxOptions{Interval: "often"},
+			wantErr: ErrInvalidFluxInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(WithFlux(tt.opts)).fluxKustomizations(cmp.Or(tt.output, "deploy/prod"), groups)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("fluxKustomizations() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fluxKustomizations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	resourceListAPIVersion = "config.kubernetes.io/v1"
+	resourceListKind       = "ResourceList"
+
+	pathAnnotation          = "config.kubernetes.io/path"
+	indexAnnotation         = "config.kubernetes.io/index"
+	internalPathAnnotation  = "internal.config.kubernetes.io/path"
+	internalIndexAnnotation = "internal.config.kubernetes.io/index"
+)
+
+var (
+	ErrNotResourceList       = errors.New("input is not a ResourceList")
+	ErrInvalidFunctionConfig = errors.New("invalid functionConfig")
+)
+
+// FunctionConfig configures splinter when it runs as a KRM function. It is read from the functionConfig of the
+// ResourceList: the data of a ConfigMap, with lists comma separated, or the spec of any other kind.
+type FunctionConfig struct {
+	// Path is the directory the split layout is placed under
+	Path                  string `yaml:"path"`
+	SeparateCRDs          bool   `yaml:"separateCRDs"`
+	SeparateClusterScoped bool   `yaml:"separateClusterScoped"`
+	// Images overrides images in the form name=newname:tag
+	Images        []string `yaml:"images"`
+	RedactSecrets bool     `yaml:"redactSecrets"`
+	// MigrateAPIs moves resources off api versions deprecated in this kubernetes version
+	MigrateAPIs string `yaml:"migrateAPIs"`
+	// Lint reports lint findings as results
+	Lint bool `yaml:"lint"`
+}
+
+// FunctionResult is a result of a KRM function run, reported in the results of the ResourceList
+type FunctionResult struct {
+	Message     string               `yaml:"message"`
+	Severity    string               `yaml:"severity"`
+	ResourceRef *FunctionResourceRef `yaml:"resourceRef,omitempty"`
+	File        *FunctionResultFile  `yaml:"file,omitempty"`
+}
+
+// FunctionResourceRef identifies the resource a result is about
+type FunctionResourceRef struct {
+	APIVersion string `yaml:"apiVersion,omitempty"`
+	Kind       string `yaml:"kind"`
+	Name       string `yaml:"name"`
+	Namespace  string `yaml:"namespace,omitempty"`
+}
+
+// FunctionResultFile is the file the resource of a result is written to
+type FunctionResultFile struct {
+	Path string `yaml:"path"`
+}
+
+// Function runs splinter as a KRM function. It reads a ResourceList, applies the transforms of the parser and the
+// functionConfig, annotates every item with the path and index it has in the split layout and writes the ResourceList
+// back with its results. rules are run against the items when the functionConfig enables lint. When the function
+// fails, the items are written back unchanged along with an error result and the error is returned.
+func (p *Parser) Function(in io.Reader, out io.Writer, rules ...Rule) error {
+	var root yaml.Node
+	if err := yaml.NewDecoder(in).Decode(&root); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotResourceList, err)
+	}
+
+	var list Resource
+	if err := root.Decode(&list); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotResourceList, err)
+	}
+	if kind, _ := list["kind"].(string); kind != resourceListKind {
+		return ErrNotResourceList
+	}
+
+	items, results, err := p.runFunction(list, resourceListItems(&root), rules)
+	if err != nil {
+		results = append(results, FunctionResult{Message: err.Error(), Severity: SeverityError})
+	} else {
+		list["items"] = items
+	}
+
+	list["apiVersion"] = resourceListAPIVersion
+	delete(list, "results")
+	if len(results) > 0 {
+		list["results"] = results
+	}
+
+	if werr := write(out, p.indentSize, list); werr != nil {
+		return werr
+	}
+
+	return err
+}
+
+// resourceListItems returns a document for every item of a ResourceList, keeping the node of each so encrypted items
+// can be decrypted
+func resourceListItems(root *yaml.Node) []document {
+	docs := make([]document, 0)
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return docs
+	}
+
+	list := root.Content[0]
+	for i := 0; i+1 < len(list.Content); i += 2 {
+		if list.Content[i].Value != "items" || list.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, item := range list.Content[i+1].Content {
+			node := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{item}}
+			r := make(Resource)
+			if item.Kind != yaml.MappingNode || node.Decode(&r) != nil {
+				continue
+			}
+			docs = append(docs, document{source: stdinSource, index: len(docs), node: node, resource: r})
+		}
+	}
+
+	return docs
+}
+
+// runFunction transforms and annotates the items of a ResourceList, returning them with the results of the run
+func (p *Parser) runFunction(list Resource, docs []document, rules []Rule) ([]Resource, []FunctionResult, error) {
+	cfg, err := functionConfig(list["functionConfig"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := p.decrypt(docs); err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]Resource, 0, len(docs))
+	for _, d := range docs {
+		items = append(items, d.resource)
+	}
+
+	fp := *p
+	fp.separateCRDs = p.separateCRDs || cfg.SeparateCRDs
+	fp.separateClusterScoped = p.separateClusterScoped || cfg.SeparateClusterScoped
+
+	transforms := make([]Transform, 0)
+	if len(cfg.Images) > 0 {
+		overrides := make([]ImageOverride, 0, len(cfg.Images))
+		for _, v := range cfg.Images {
+			o, err := ParseImageOverride(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			overrides = append(overrides, o)
+		}
+		transforms = append(transforms, ImageOverrideTransform(overrides...))
+	}
+	if cfg.RedactSecrets {
+		transforms = append(transforms, RedactSecretsTransform())
+	}
+	if cfg.MigrateAPIs != "" {
+		migrate, err := MigrateAPIsTransform(cfg.MigrateAPIs)
+		if err != nil {
+			return nil, nil, err
+		}
+		transforms = append(transforms, migrate)
+	}
+	// transforms configured on the parser run last so encryption still sees the final values
+	fp.transforms = append(transforms, p.transforms...)
+
+	items, err = fp.transform(items)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	docs = fp.annotatePaths(items, cfg.Path)
+
+	results := make([]FunctionResult, 0)
+	if cfg.Lint {
+		for _, d := range docs {
+			for _, f := range lint([]document{d}, items, rules) {
+				results = append(results, newFunctionResult(f, d.resource))
+			}
+		}
+	}
+
+	return items, results, nil
+}
+
+// annotatePaths sets the path and index annotations kpt and kustomize write items to, following the split layout
+// under dir. It returns a document per annotated item for reporting.
+func (p *Parser) annotatePaths(items []Resource, dir string) []document {
+	clusterKinds := clusterScopedCustomKinds(items)
+
+	docs := make([]document, 0, len(items))
+	indexes := make(map[string]int)
+	for _, r := range items {
+		kind, ok := r["kind"].(string)
+		if !ok || kind == "" {
+			continue
+		}
+
+		file := path.Join(dir, p.kindFile(kind, clusterKinds))
+		index := strconv.Itoa(indexes[file])
+		indexes[file]++
+
+		metadata, ok := nestedMap(r, "metadata")
+		if !ok {
+			metadata = make(Resource)
+			r["metadata"] = metadata
+		}
+		annotations, ok := nestedMap(metadata, "annotations")
+		if !ok {
+			annotations = make(Resource)
+			metadata["annotations"] = annotations
+		}
+		annotations[pathAnnotation] = file
+		annotations[indexAnnotation] = index
+		annotations[internalPathAnnotation] = file
+		annotations[internalIndexAnnotation] = index
+
+		docs = append(docs, document{source: file, index: len(docs), node: &yaml.Node{}, resource: r})
+	}
+
+	return docs
+}
+
+// functionConfig reads the FunctionConfig from the functionConfig of a ResourceList
+func functionConfig(v any) (FunctionConfig, error) {
+	var cfg FunctionConfig
+	r, ok := asMap(v)
+	if !ok {
+		return cfg, nil
+	}
+
+	if kind, _ := r["kind"].(string); kind != "ConfigMap" {
+		spec, ok := nestedMap(r, "spec")
+		if !ok {
+			return cfg, nil
+		}
+
+		var node yaml.Node
+		if err := node.Encode(spec); err != nil {
+			return cfg, err
+		}
+		if err := node.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("%w: %w", ErrInvalidFunctionConfig, err)
+		}
+		return cfg, nil
+	}
+
+	data, _ := nestedMap(r, "data")
+	for k, v := range data {
+		value := fmt.Sprint(v)
+		var err error
+		switch k {
+		case "path":
+			cfg.Path = value
+		case "separateCRDs":
+			cfg.SeparateCRDs, err = strconv.ParseBool(value)
+		case "separateClusterScoped":
+			cfg.SeparateClusterScoped, err = strconv.ParseBool(value)
+		case "images":
+			for _, image := range strings.Split(value, ",") {
+				if image = strings.TrimSpace(image); image != "" {
+					cfg.Images = append(cfg.Images, image)
+				}
+			}
+		case "redactSecrets":
+			cfg.RedactSecrets, err = strconv.ParseBool(value)
+		case "migrateAPIs":
+			cfg.MigrateAPIs = value
+		case "lint":
+			cfg.Lint, err = strconv.ParseBool(value)
+		default:
+			err = errors.New("unknown key")
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("%w: %s: %w", ErrInvalidFunctionConfig, k, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func newFunctionResult(f Finding, r Resource) FunctionResult {
+	kind, _ := r.Kind()
+	apiVersion, _ := r["apiVersion"].(string)
+	return FunctionResult{
+		Message:  fmt.Sprintf("[%s] %s", f.Rule, f.Message),
+		Severity: f.Severity,
+		ResourceRef: &FunctionResourceRef{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       r.Name(),
+			Namespace:  r.Namespace(),
+		},
+		File: &FunctionResultFile{Path: f.Source},
+	}
+}
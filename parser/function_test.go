@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParser_Function(t *testing.T) {
+	items := `items:
+  - apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: web
+    spec:
+      template:
+        spec:
+          containers:
+            - name: web
+              image: nginx:1.25
+  - apiVersion: apiextensions.k8s.io/v1
+    kind: CustomResourceDefinition
+    metadata:
+      name: foos.example.com
+`
+
+	tests := []struct {
+		name    string
+		input   string
+		rules   []Rule
+		want    string
+		wantErr error
+	}{
+		{
+			name: "configmap functionConfig",
+			input: `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+functionConfig:
+  apiVersion: v1
+  kind: ConfigMap
+  data:
+    path: manifests
+    separateCRDs: "true"
+    images: nginx=registry.local/nginx:1.26
+` + items,
+			want: `apiVersion: config.kubernetes.io/v1
+functionConfig:
+  apiVersion: v1
+  data:
+    images: nginx=registry.local/nginx:1.26
+    path: manifests
+    separateCRDs: "true"
+  kind: ConfigMap
+items:
+  - apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      annotations:
+        config.kubernetes.io/index: "0"
+        config.kubernetes.io/path: manifests/deployment.yaml
+        internal.config.kubernetes.io/index: "0"
+        internal.config.kubernetes.io/path: manifests/deployment.yaml
+      name: web
+    spec:
+      template:
+        spec:
+          containers:
+            - image: registry.local/nginx:1.26
+              name: web
+  - apiVersion: apiextensions.k8s.io/v1
+    kind: CustomResourceDefinition
+    metadata:
+      annotations:
+        config.kubernetes.io/index: "0"
+        config.kubernetes.io/path: manifests/crds/customresourcedefinition.yaml
+        internal.config.kubernetes.io/index: "0"
+        internal.config.kubernetes.io/path: manifests/crds/customresourcedefinition.yaml
+      name: foos.example.com
+kind: ResourceList
+`,
+		},
+		{
+			name: "spec functionConfig with lint",
+			input: `kind: ResourceList
+functionConfig:
+  apiVersion: splinter.kdwils.dev/v1alpha1
+  kind: Splinter
+  spec:
+    lint: true
+items:
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      name: web
+`,
+			rules: []Rule{NewRule("named", "", SeverityWarning, func(r Resource, _ []Resource) []string {
+				return []string{"found " + r.Name()}
+			})},
+			want: `apiVersion: config.kubernetes.io/v1
+functionConfig:
+  apiVersion: splinter.kdwils.dev/v1alpha1
+  kind: Splinter
+  spec:
+    lint: true
+items:
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      annotations:
+        config.kubernetes.io/index: "0"
+        config.kubernetes.io/path: service.yaml
+        internal.config.kubernetes.io/index: "0"
+        internal.config.kubernetes.io/path: service.yaml
+      name: web
+kind: ResourceList
+results:
+  - message: '[named] found web'
+    severity: warning
+    resourceRef:
+      apiVersion: v1
+      kind: Service
+      name: web
+    file:
+      path: service.yaml
+`,
+		},
+		{
+			name: "invalid functionConfig keeps the items",
+			input: `kind: ResourceList
+functionConfig:
+  kind: ConfigMap
+  data:
+    separateCRDs: maybe
+items:
+  - kind: Service
+    metadata:
+      name: web
+`,
+			want: `apiVersion: config.kubernetes.io/v1
+functionConfig:
+  data:
+    separateCRDs: maybe
+  kind: ConfigMap
+items:
+  - kind: Service
+    metadata:
+      name: web
+kind: ResourceList
+results:
+  - message: 'invalid functionConfig: separateCRDs: strconv.ParseBool: parsing "maybe": invalid syntax'
+    severity: error
+`,
+			wantErr: ErrInvalidFunctionConfig,
+		},
+		{
+			name:    "not a resource list",
+			input:   "kind: Service\n",
+			wantErr: ErrNotResourceList,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := New().Function(strings.NewReader(tt.input), &out, tt.rules...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Function() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("Function() output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
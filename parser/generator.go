@@ -0,0 +1,388 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrExtractRequiresKustomize = errors.New("extracting data requires a kustomization")
+	ErrUnsafePath               = errors.New("unsafe path")
+	ErrInvalidGenerator         = errors.New("invalid generator")
+)
+
+const (
+	configMapGeneratorKey = "configMapGenerator"
+	secretGeneratorKey    = "secretGenerator"
+)
+
+// generator is a kustomize configMapGenerator or secretGenerator entry
+type generator struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Type      string            `yaml:"type,omitempty"`
+	Behavior  string            `yaml:"behavior,omitempty"`
+	Files     []string          `yaml:"files,omitempty"`
+	Literals  []string          `yaml:"literals,omitempty"`
+	Envs      []string          `yaml:"envs,omitempty"`
+	Options   *generatorOptions `yaml:"options,omitempty"`
+}
+
+type generatorOptions struct {
+	DisableNameSuffixHash bool              `yaml:"disableNameSuffixHash,omitempty"`
+	Immutable             bool              `yaml:"immutable,omitempty"`
+	Labels                map[string]string `yaml:"labels,omitempty"`
+	Annotations           map[string]string `yaml:"annotations,omitempty"`
+}
+
+// WithExtractData writes ConfigMap and Secret data as files on split and replaces the objects with generators
+func WithExtractData(extract bool) ParserOpt {
+	return func(p *Parser) {
+		p.extractData = extract
+	}
+}
+
+// extract writes the data of every ConfigMap and unencrypted Secret under outputPath and returns the remaining resources
+// along with the generator entries, keyed by the kustomization field they belong in
+func (p *Parser) extract(outputPath string, resources []Resource) ([]Resource, map[string][]generator, error) {
+	remaining := make([]Resource, 0, len(resources))
+	generators := make(map[string][]generator)
+
+	for _, r := range resources {
+		key, dir := generatorKind(r)
+		if key == "" || r.Name() == "" || isEncrypted(r) {
+			remaining = append(remaining, r)
+			continue
+		}
+
+		g, files, err := newGenerator(r, dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", r.Ref(), err)
+		}
+
+		for _, name := range g.Files {
+			target := path.Join(outputPath, name)
+			if !withinDir(outputPath, target) {
+				return nil, nil, fmt.Errorf("%s: %w: %s", r.Ref(), ErrUnsafePath, name)
+			}
+			if err := p.writeFile(target, files[name]); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		generators[key] = append(generators[key], g)
+	}
+
+	return remaining, generators, nil
+}
+
+// generatorKind returns the kustomization field and output directory for resources that can be generated
+func generatorKind(r Resource) (string, string) {
+	kind, _ := r.Kind()
+	switch kind {
+	case "ConfigMap":
+		return configMapGeneratorKey, "configmaps"
+	case "Secret":
+		return secretGeneratorKey, "secrets"
+	default:
+		return "", ""
+	}
+}
+
+// generatorDir returns the directory under dir the data of a ConfigMap or Secret is written to, which includes the
+// namespace so objects with the same name in different namespaces don't collide
+func generatorDir(r Resource, dir string) (string, error) {
+	segments := []string{dir}
+	if ns := r.Namespace(); ns != "" {
+		segments = append(segments, ns)
+	}
+	segments = append(segments, r.Name())
+
+	for _, s := range segments[1:] {
+		if err := checkPathSegment(s); err != nil {
+			return "", err
+		}
+	}
+	return path.Join(segments...), nil
+}
+
+// checkPathSegment fails for names that would not map to a single file or directory within the output
+func checkPathSegment(s string) error {
+	if s == "" || s == "." || s == ".." || strings.ContainsAny(s, `/\`) {
+		return fmt.Errorf("%w: %q", ErrUnsafePath, s)
+	}
+	return nil
+}
+
+// withinDir reports whether name is dir or a path below it
+func withinDir(dir, name string) bool {
+	dir, name = path.Clean(dir), path.Clean(name)
+	if dir == "." {
+		return name != ".." && !strings.HasPrefix(name, "../") && !path.IsAbs(name)
+	}
+	return name == dir || strings.HasPrefix(name, strings.TrimSuffix(dir, "/")+"/")
+}
+
+// newGenerator builds a generator entry for a ConfigMap or Secret and the file contents keyed by relative path.
+// The files are written below dir/<namespace>/<name>.
+func newGenerator(r Resource, dir string) (generator, map[string][]byte, error) {
+	kind, _ := r.Kind()
+	data := make(map[string][]byte)
+
+	dir, err := generatorDir(r, dir)
+	if err != nil {
+		return generator{}, nil, err
+	}
+
+	for _, field := range []string{"data", "binaryData", "stringData"} {
+		values, _ := asMap(r[field])
+		for k, v := range values {
+			s := fmt.Sprint(v)
+			if field == "binaryData" || (kind == "Secret" && field == "data") {
+				b, err := base64.StdEncoding.DecodeString(s)
+				if err != nil {
+					return generator{}, nil, fmt.Errorf("decoding %s.%s: %w", field, k, err)
+				}
+				data[k] = b
+				continue
+			}
+			data[k] = []byte(s)
+		}
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	files := make(map[string][]byte, len(keys))
+	g := generator{
+		Name:      r.Name(),
+		Namespace: r.Namespace(),
+		Files:     make([]string, 0, len(keys)),
+		Options: &generatorOptions{
+			DisableNameSuffixHash: true,
+			Labels:                r.metadataStrings("labels"),
+			Annotations:           r.metadataStrings("annotations"),
+		},
+	}
+	if t, ok := r["type"].(string); ok && kind == "Secret" {
+		g.Type = t
+	}
+
+	for _, k := range keys {
+		if err := checkPathSegment(k); err != nil {
+			return generator{}, nil, fmt.Errorf("%s key: %w", kind, err)
+		}
+		name := path.Join(dir, k)
+		g.Files = append(g.Files, name)
+		files[name] = data[k]
+	}
+
+	return g, files, nil
+}
+
+// expandGenerators reads the files, env files and literals of a kustomization's generators relative to dir and
+// returns the ConfigMaps and Secrets they describe. The generator entries are removed from the kustomization.
+// Entries with fields that can't be rebuilt, or merging into a generator of a base, fail with ErrInvalidGenerator.
+func (p *Parser) expandGenerators(dir string, kustomization Resource) ([]Resource, error) {
+	resources := make([]Resource, 0)
+	for _, key := range []string{configMapGeneratorKey, secretGeneratorKey} {
+		v, ok := kustomization[key]
+		if !ok {
+			continue
+		}
+
+		var generators []generator
+		if err := convert(v, &generators); err != nil {
+			return nil, fmt.Errorf("reading %s: %w: %v", key, ErrInvalidGenerator, err)
+		}
+
+		for _, g := range generators {
+			r, err := p.resourceFromGenerator(dir, key, g)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, r)
+		}
+
+		delete(kustomization, key)
+	}
+
+	return resources, nil
+}
+
+func (p *Parser) resourceFromGenerator(dir, key string, g generator) (Resource, error) {
+	metadata := Resource{"name": g.Name}
+	if g.Namespace != "" {
+		metadata["namespace"] = g.Namespace
+	}
+	if g.Options != nil && len(g.Options.Labels) > 0 {
+		metadata["labels"] = g.Options.Labels
+	}
+	if g.Options != nil && len(g.Options.Annotations) > 0 {
+		metadata["annotations"] = g.Options.Annotations
+	}
+
+	r := Resource{
+		"apiVersion": "v1",
+		"metadata":   metadata,
+	}
+
+	values, err := p.generatorValues(dir, g)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", key, g.Name, err)
+	}
+
+	data := make(Resource)
+	binaryData := make(Resource)
+	for k, b := range values {
+		switch {
+		case key == secretGeneratorKey:
+			data[k] = base64.StdEncoding.EncodeToString(b)
+		case utf8.Valid(b):
+			data[k] = string(b)
+		default:
+			binaryData[k] = base64.StdEncoding.EncodeToString(b)
+		}
+	}
+
+	if key == secretGeneratorKey {
+		r["kind"] = "Secret"
+		if g.Type != "" {
+			r["type"] = g.Type
+		}
+	} else {
+		r["kind"] = "ConfigMap"
+	}
+	if len(data) > 0 {
+		r["data"] = data
+	}
+	if len(binaryData) > 0 {
+		r["binaryData"] = binaryData
+	}
+	if g.Options != nil && g.Options.Immutable {
+		r["immutable"] = true
+	}
+
+	return r, nil
+}
+
+// generatorValues returns the values of a generator's env files, files and literals by key
+func (p *Parser) generatorValues(dir string, g generator) (map[string][]byte, error) {
+	if g.Behavior != "" && g.Behavior != "create" {
+		return nil, fmt.Errorf("%w: behavior %s needs the generator of a base", ErrInvalidGenerator, g.Behavior)
+	}
+
+	values := make(map[string][]byte)
+	add := func(k string, v []byte) error {
+		if k == "" {
+			return fmt.Errorf("%w: empty key", ErrInvalidGenerator)
+		}
+		if _, ok := values[k]; ok {
+			return fmt.Errorf("%w: duplicate key %q", ErrInvalidGenerator, k)
+		}
+		values[k] = v
+		return nil
+	}
+
+	for _, name := range g.Envs {
+		b, err := p.readGeneratorFile(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			line = strings.TrimLeftFunc(line, unicode.IsSpace)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			// kustomize reads a key without a value from its own environment
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: %q has no value", ErrInvalidGenerator, name, k)
+			}
+			if err := add(k, []byte(v)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, f := range g.Files {
+		k, name, ok := strings.Cut(f, "=")
+		if !ok {
+			k, name = path.Base(f), f
+		}
+
+		b, err := p.readGeneratorFile(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(k, b); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, l := range g.Literals {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: literal %q is not key=value", ErrInvalidGenerator, l)
+		}
+		if err := add(k, []byte(unquote(v))); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// unquote removes the matching single or double quotes kustomize strips from literal values
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// readGeneratorFile reads a file referenced by a generator, which like kustomize must be within the
+// kustomization's directory
+func (p *Parser) readGeneratorFile(dir, name string) ([]byte, error) {
+	target := path.Join(dir, name)
+	if !withinDir(dir, target) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	return p.fio.ReadFile(target)
+}
+
+// convert round trips a decoded yaml value into out, failing on fields out has no place for
+func convert(in any, out any) error {
+	b, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	return dec.Decode(out)
+}
+
+func (p *Parser) writeFile(name string, data []byte) error {
+	if _, err := p.fio.Stat(path.Dir(name)); errors.Is(err, os.ErrNotExist) {
+		err := p.fio.MkdirAll(path.Dir(name), os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.fio.WriteFile(name, data, 0o644)
+}
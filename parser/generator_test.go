@@ -0,0 +1,352 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kdwils/splinter/pkg/fio"
+	"github.com/kdwils/splinter/pkg/fio/mocks"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_newGenerator(t *testing.T) {
+	tests := []struct {
+		name      string
+		resource  Resource
+		want      generator
+		wantFiles map[string][]byte
+		wantErr   error
+	}{
+		{
+			name: "configmap with data and binaryData",
+			resource: Resource{
+				"kind":       "ConfigMap",
+				"metadata":   Resource{"name": "nginx", "namespace": "web", "labels": Resource{"app": "nginx"}},
+				"data":       Resource{"nginx.conf": "listen 80;\n"},
+				"binaryData": Resource{"blob.bin": "AAEC/w=="},
+			},
+			want: generator{
+				Name:      "nginx",
+				Namespace: "web",
+				Files:     []string{"configmaps/web/nginx/blob.bin", "configmaps/web/nginx/nginx.conf"},
+				Options: &generatorOptions{
+					DisableNameSuffixHash: true,
+					Labels:                map[string]string{"app": "nginx"},
+				},
+			},
+			wantFiles: map[string][]byte{
+				"configmaps/web/nginx/blob.bin":   {0x00, 0x01, 0x02, 0xff},
+				"configmaps/web/nginx/nginx.conf": []byte("listen 80;\n"),
+			},
+		},
+		{
+			name: "secret data is decoded",
+			resource: Resource{
+				"kind":       "Secret",
+				"type":       "Opaque",
+				"metadata":   Resource{"name": "creds"},
+				"data":       Resource{"password": "aHVudGVyMg=="},
+				"stringData": Resource{"user": "admin"},
+			},
+			want: generator{
+				Name:    "creds",
+				Type:    "Opaque",
+				Files:   []string{"secrets/creds/password", "secrets/creds/user"},
+				Options: &generatorOptions{DisableNameSuffixHash: true},
+			},
+			wantFiles: map[string][]byte{
+				"secrets/creds/password": []byte("hunter2"),
+				"secrets/creds/user":     []byte("admin"),
+			},
+		},
+		{
+			name: "name escaping the output",
+			resource: Resource{
+				"kind":     "ConfigMap",
+				"metadata": Resource{"name": "../../escaped"},
+				"data":     Resource{"pwned.txt": "x"},
+			},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name: "parent directory namespace",
+			resource: Resource{
+				"kind":     "ConfigMap",
+				"metadata": Resource{"name": "a", "namespace": ".."},
+				"data":     Resource{"key": "x"},
+			},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name: "key with a separator",
+			resource: Resource{
+				"kind":       "Secret",
+				"metadata":   Resource{"name": "creds"},
+				"stringData": Resource{"../password": "x"},
+			},
+			wantErr: ErrUnsafePath,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, dir := generatorKind(tt.resource)
+			got, files, err := newGenerator(tt.resource, dir)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("newGenerator() error = %v, want %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newGenerator() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("newGenerator() files = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestParser_expandGenerators(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFio := mocks.NewMockFileIO(ctrl)
+
+	mockFio.EXPECT().ReadFile("out/configmaps/nginx/nginx.conf").Return([]byte("listen 80;\n"), nil)
+	mockFio.EXPECT().ReadFile("out/configmaps/nginx/blob.bin").Return([]byte{0x00, 0xff}, nil)
+	mockFio.EXPECT().ReadFile("out/secrets/creds/password").Return([]byte("hunter2"), nil)
+
+	kustomization := Resource{
+		"kind":      "Kustomization",
+		"resources": []any{"service.yaml"},
+		"configMapGenerator": []any{
+			Resource{
+				"name":      "nginx",
+				"namespace": "web",
+				"files":     []any{"configmaps/nginx/nginx.conf", "configmaps/nginx/blob.bin"},
+			},
+		},
+		"secretGenerator": []any{
+			Resource{
+				"name":  "creds",
+				"type":  "Opaque",
+				"files": []any{"secrets/creds/password"},
+			},
+		},
+	}
+
+	want := []Resource{
+		{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   Resource{"name": "nginx", "namespace": "web"},
+			"data":       Resource{"nginx.conf": "listen 80;\n"},
+			"binaryData": Resource{"blob.bin": "AP8="},
+		},
+		{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"type":       "Opaque",
+			"metadata":   Resource{"name": "creds"},
+			"data":       Resource{"password": "aHVudGVyMg=="},
+		},
+	}
+
+	p := New(WithFileIO(mockFio))
+	got, err := p.expandGenerators("out", kustomization)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandGenerators() = %v, want %v", got, want)
+	}
+	if _, ok := kustomization[configMapGeneratorKey]; ok {
+		t.Error("expected configMapGenerator to be removed from the kustomization")
+	}
+}
+
+func TestParser_expandGenerators_literalsAndEnvs(t *testing.T) {
+	m := fio.NewMemory()
+	m.AddFile("out/app.env", []byte("# comment\n  A=1\nB=\"q\"\n\nC=x=y\n"))
+
+	kustomization := Resource{
+		"configMapGenerator": []any{
+			Resource{
+				"name":     "settings",
+				"behavior": "create",
+				"literals": []any{"k=v", `q="quoted"`, "s='single'", "e="},
+				"envs":     []any{"app.env"},
+				"options":  Resource{"immutable": true},
+			},
+		},
+		"secretGenerator": []any{
+			Resource{"name": "creds", "literals": []any{"password=hunter2"}},
+		},
+	}
+
+	want := []Resource{
+		{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   Resource{"name": "settings"},
+			"data":       Resource{"A": "1", "B": `"q"`, "C": "x=y", "e": "", "k": "v", "q": "quoted", "s": "single"},
+			"immutable":  true,
+		},
+		{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   Resource{"name": "creds"},
+			"data":       Resource{"password": "aHVudGVyMg=="},
+		},
+	}
+
+	got, err := New(WithFileIO(m)).expandGenerators("out", kustomization)
+	if err != nil {
+		t.Fatalf("expandGenerators() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandGenerators() = %v, want %v", got, want)
+	}
+}
+
+func TestParser_expandGenerators_invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		generator Resource
+	}{
+		{name: "unknown field", generator: Resource{"name": "x", "env": "app.env"}},
+		{name: "merge behavior", generator: Resource{"name": "x", "behavior": "merge", "literals": []any{"k=v"}}},
+		{name: "literal without a value", generator: Resource{"name": "x", "literals": []any{"k"}}},
+		{name: "env without a value", env: "A=1\nHOME\n", generator: Resource{"name": "x", "envs": []any{"app.env"}}},
+		{name: "duplicate key", env: "A=1\n", generator: Resource{"name": "x", "literals": []any{"A=2"}, "envs": []any{"app.env"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := fio.NewMemory()
+			m.AddFile("out/app.env", []byte(tt.env))
+			kustomization := Resource{"configMapGenerator": []any{tt.generator}}
+
+			if _, err := New(WithFileIO(m)).expandGenerators("out", kustomization); !errors.Is(err, ErrInvalidGenerator) {
+				t.Errorf("expandGenerators() error = %v, want %v", err, ErrInvalidGenerator)
+			}
+			if _, ok := kustomization[configMapGeneratorKey]; !ok {
+				t.Error("expected configMapGenerator to be kept")
+			}
+		})
+	}
+}
+
+func TestParser_expandGenerators_unsafePath(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+		file string
+	}{
+		{name: "parent directory", dir: "out", file: "../passwd"},
+		{name: "keyed parent directory", dir: "out", file: "x=../../../passwd"},
+		{name: "parent of the working directory", dir: ".", file: "x=../passwd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := fio.NewMemory()
+			m.AddFile("passwd", []byte("root:x:0:0\n"))
+			kustomization := Resource{
+				"configMapGenerator": []any{Resource{"name": "x", "files": []any{tt.file}}},
+			}
+
+			if _, err := New(WithFileIO(m)).expandGenerators(tt.dir, kustomization); !errors.Is(err, ErrUnsafePath) {
+				t.Errorf("expandGenerators() error = %v, want %v", err, ErrUnsafePath)
+			}
+		})
+	}
+}
+
+func TestParser_Split_extractRequiresKustomize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFio := mocks.NewMockFileIO(ctrl)
+	mockFio.EXPECT().ReadFile("input.yaml").Return([]byte("kind: ConfigMap\nmetadata:\n  name: a\n"), nil)
+
+	p := New(WithFileIO(mockFio), WithExtractData(true))
+	if err := p.Split([]string{"input.yaml"}, nil, "output", false); err != ErrExtractRequiresKustomize {
+		t.Errorf("expected %v, got %v", ErrExtractRequiresKustomize, err)
+	}
+}
+
+func TestParser_Split_extractData(t *testing.T) {
+	input := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: a
+data:
+  app.conf: one
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: b
+data:
+  app.conf: two
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+	m := fio.NewMemory()
+	m.AddFile("input.yaml", []byte(input))
+
+	p := New(WithFileIO(m), WithExtractData(true))
+	if err := p.Split([]string{"input.yaml"}, nil, "out", true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"out/configmaps/a/settings/app.conf": "one",
+		"out/configmaps/b/settings/app.conf": "two",
+	} {
+		if got, _ := m.Contents(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	k, _ := m.Contents("out/kustomization.yaml")
+	var kustomization struct {
+		Resources          []string    `yaml:"resources"`
+		ConfigMapGenerator []generator `yaml:"configMapGenerator"`
+	}
+	if err := yaml.Unmarshal([]byte(k), &kustomization); err != nil {
+		t.Fatalf("reading kustomization: %v", err)
+	}
+
+	wantGenerators := []generator{
+		{Name: "settings", Namespace: "a", Files: []string{"configmaps/a/settings/app.conf"}, Options: &generatorOptions{DisableNameSuffixHash: true}},
+		{Name: "settings", Namespace: "b", Files: []string{"configmaps/b/settings/app.conf"}, Options: &generatorOptions{DisableNameSuffixHash: true}},
+	}
+	if !reflect.DeepEqual(kustomization.ConfigMapGenerator, wantGenerators) {
+		t.Errorf("configMapGenerator = %+v, want %+v", kustomization.ConfigMapGenerator, wantGenerators)
+	}
+	if !reflect.DeepEqual(kustomization.Resources, []string{"service.yaml"}) {
+		t.Errorf("resources = %v, want [service.yaml]", kustomization.Resources)
+	}
+}
+
+func TestParser_Split_extractDataUnsafeName(t *testing.T) {
+	m := fio.NewMemory()
+	m.AddFile("input.yaml", []byte("kind: ConfigMap\nmetadata:\n  name: ../../escaped\ndata:\n  pwned.txt: x\n"))
+
+	p := New(WithFileIO(m), WithExtractData(true))
+	if err := p.Split([]string{"input.yaml"}, nil, "out", true); !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("expected %v, got %v", ErrUnsafePath, err)
+	}
+	if files := m.Files(); len(files) != 1 {
+		t.Errorf("expected only the input, got %v", files)
+	}
+}
@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kdwils/splinter/pkg/fio"
+	"github.com/kdwils/splinter/pkg/git"
+)
+
+const gitInputPrefix = "git:"
+
+var ErrInvalidGitInput = errors.New("git input must be git:<rev>:<path>")
+
+// isGitInput reports whether an input names files at a revision of the local git repository
+func isGitInput(input string) bool {
+	return strings.HasPrefix(input, gitInputPrefix)
+}
+
+// parseGitInput splits a git:<rev>:<path> input. Like git, the path is relative to the root of the repository
+// unless it starts with ./ or ../, in which case it is relative to the working directory.
+func parseGitInput(input string) (string, string, error) {
+	rev, p, ok := strings.Cut(strings.TrimPrefix(input, gitInputPrefix), ":")
+	if !ok || rev == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidGitInput, input)
+	}
+	return rev, p, nil
+}
+
+// gitStreams reads the yaml files a git input names from the object store of the repository containing the working
+// directory, without checking the revision out. Streams are named git:<rev>:<path>.
+func (p *Parser) gitStreams(input string) ([]Stream, error) {
+	rev, name, err := parseGitInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := git.Open(".")
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "." || name == ".." || strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../") {
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(repo.Worktree(), abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("%w: %s is outside of the repository", ErrInvalidGitInput, name)
+		}
+		name = filepath.ToSlash(rel)
+	}
+
+	commit, err := repo.Resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	if p.lock != nil {
+		p.lock.revision(input, commit.String())
+	}
+
+	fsys, err := repo.FS(commit.String())
+	if err != nil {
+		return nil, err
+	}
+
+	gp := *p
+	gp.fio = fio.NewFS(fsys)
+
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	files := gp.filesFromInput([]string{name})
+	if len(files) == 0 {
+		if _, err := gp.fio.Stat(name); err != nil {
+			return nil, fmt.Errorf("%s: %w", input, err)
+		}
+	}
+
+	streams := make([]Stream, 0, len(files))
+	for _, f := range files {
+		if ArchiveFormat(f) != "" {
+			archived, err := gp.archiveStreams(f)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range archived {
+				s.Name = gitInputPrefix + rev + ":" + s.Name
+				streams = append(streams, s)
+			}
+			continue
+		}
+
+		b, err := gp.fio.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s%s:%s: %w", gitInputPrefix, rev, f, err)
+		}
+		streams = append(streams, Stream{Name: gitInputPrefix + rev + ":" + f, Reader: bytes.NewReader(b), files: gp.fio, path: f})
+	}
+
+	return streams, nil
+}
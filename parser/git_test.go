@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGitInput(t *testing.T) {
+	tests := []struct {
+		input   string
+		rev     string
+		path    string
+		wantErr error
+	}{
+		{input: "git:main:deploy/", rev: "main", path: "deploy/"},
+		{input: "git:HEAD~1:./", rev: "HEAD~1", path: "./"},
+		{input: "git:v1.0:", rev: "v1.0", path: ""},
+		{input: "git:main", wantErr: ErrInvalidGitInput},
+		{input: "git::deploy", wantErr: ErrInvalidGitInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			rev, path, err := parseGitInput(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseGitInput() error = %v, want %v", err, tt.wantErr)
+			}
+			if rev != tt.rev || path != tt.path {
+				t.Errorf("parseGitInput() = %q, %q, want %q, %q", rev, path, tt.rev, tt.path)
+			}
+		})
+	}
+}
+
+func TestParser_gitInput(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=splinter", "GIT_AUTHOR_EMAIL=splinter@example.com",
+			"GIT_COMMITTER_NAME=splinter", "GIT_COMMITTER_EMAIL=splinter@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	writeDeployment := func(replicas string) {
+		manifest := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: " + replicas + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "deploy", "deployment.yaml"), []byte(manifest), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "deploy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "config", "configmaps"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	kustomization := "kind: Kustomization\nconfigMapGenerator:\n  - name: settings\n    files:\n      - configmaps/app.conf\n"
+	if err := os.WriteFile(filepath.Join(dir, "config", "kustomization.yaml"), []byte(kustomization), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config", "configmaps", "app.conf"), []byte("committed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-q", "-b", "main")
+	writeDeployment("1")
+	git("add", "-A")
+	git("commit", "-q", "-m", "one replica")
+	writeDeployment("2")
+	git("commit", "-q", "-am", "two replicas")
+	writeDeployment("3")
+
+	t.Chdir(filepath.Join(dir, "deploy"))
+
+	tests := []struct {
+		name     string
+		input    string
+		replicas int
+		source   string
+	}{
+		{name: "repository path", input: "git:main:deploy/", replicas: 2, source: "git:main:deploy/deployment.yaml"},
+		{name: "working directory path", input: "git:HEAD~1:./", replicas: 1, source: "git:HEAD~1:deploy/deployment.yaml"},
+		{name: "file", input: "git:main:deploy/deployment.yaml", replicas: 2, source: "git:main:deploy/deployment.yaml"},
+		{name: "working tree", input: "deployment.yaml", replicas: 3, source: "deployment.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			streams, err := p.inputStreams([]string{tt.input})
+			if err != nil {
+				t.Fatalf("inputStreams() error = %v", err)
+			}
+			if len(streams) != 1 || streams[0].Name != tt.source {
+				t.Fatalf("inputStreams() = %v, want a single stream named %s", streams, tt.source)
+			}
+
+			set, err := p.Read(context.Background(), Files(tt.input))
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			spec, _ := nestedMap(set.Resources()[0], "spec")
+			if spec["replicas"] != tt.replicas {
+				t.Errorf("replicas = %v, want %d", spec["replicas"], tt.replicas)
+			}
+		})
+	}
+
+	diffs, err := New().Diff([]string{"git:main:deploy"}, []string{"."})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Status != DiffChanged {
+		t.Errorf("Diff() = %v, want Deployment/web changed", diffs)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config", "configmaps", "app.conf"), []byte("working tree"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	set, err := New(WithExtractData(true)).Read(context.Background(), Files("git:main:../config"))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if rs := set.Resources(); len(rs) == 0 {
+		t.Error("Read() = no resources, want the generated ConfigMap")
+	} else if data, _ := nestedMap(rs[0], "data"); data["app.conf"] != "committed" {
+		t.Errorf("app.conf = %v, want the committed file", data["app.conf"])
+	}
+
+	for _, input := range []string{"git:missing:deploy", "git:main:missing", "git:main:../../outside"} {
+		if _, err := New().inputStreams([]string{input}); err == nil {
+			t.Errorf("inputStreams(%q) error = nil, want an error", input)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidImageOverride = errors.New("image override must be in the form name=newname:tag")
+)
+
+// podSpecPaths maps each workload kind to the path of its pod spec
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// ImageOverride replaces every image named Name with NewName, NewTag and Digest when they are set
+type ImageOverride struct {
+	Name    string
+	NewName string
+	NewTag  string
+	Digest  string
+}
+
+// ImageUsage is a single image reference and the resources that use it
+type ImageUsage struct {
+	Image     string   `json:"image"`
+	Resources []string `json:"resources"`
+}
+
+// ParseImageOverride parses an override in the form name=newname:tag, name=newname@digest or name=:tag
+func ParseImageOverride(s string) (ImageOverride, error) {
+	name, replacement, ok := strings.Cut(s, "=")
+	if !ok || name == "" || replacement == "" {
+		return ImageOverride{}, fmt.Errorf("%w: %q", ErrInvalidImageOverride, s)
+	}
+
+	newName, tag, digest := splitImage(replacement)
+	return ImageOverride{
+		Name:    name,
+		NewName: newName,
+		NewTag:  tag,
+		Digest:  digest,
+	}, nil
+}
+
+// ImageOverrideTransform returns a transform that rewrites container and initContainer images of every workload
+func ImageOverrideTransform(overrides ...ImageOverride) Transform {
+	return func(resources []Resource) ([]Resource, error) {
+		for _, r := range resources {
+			for _, c := range r.containers() {
+				image, ok := c["image"].(string)
+				if !ok {
+					continue
+				}
+				c["image"] = overrideImage(image, overrides)
+			}
+		}
+
+		return resources, nil
+	}
+}
+
+func overrideImage(image string, overrides []ImageOverride) string {
+	name, tag, digest := splitImage(image)
+	for _, o := range overrides {
+		if o.Name != name {
+			continue
+		}
+		if o.NewName != "" {
+			name = o.NewName
+		}
+		if o.NewTag != "" {
+			tag, digest = o.NewTag, ""
+		}
+		if o.Digest != "" {
+			tag, digest = "", o.Digest
+		}
+		break
+	}
+
+	return joinImage(name, tag, digest)
+}
+
+// splitImage splits an image reference into its name, tag and digest
+func splitImage(image string) (string, string, string) {
+	name, digest, _ := strings.Cut(image, "@")
+
+	var tag string
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+
+	return name, tag, digest
+}
+
+func joinImage(name, tag, digest string) string {
+	if tag != "" {
+		name += ":" + tag
+	}
+	if digest != "" {
+		name += "@" + digest
+	}
+	return name
+}
+
+// containers returns the containers and initContainers of a workload's pod spec
+func (r Resource) containers() []map[string]any {
+	return r.podContainers("initContainers", "containers")
+}
+
+// podSpec returns the pod spec of a workload
+func (r Resource) podSpec() (map[string]any, bool) {
+	kind, err := r.Kind()
+	if err != nil {
+		return nil, false
+	}
+
+	path, ok := podSpecPaths[kind]
+	if !ok {
+		return nil, false
+	}
+
+	return nestedMap(r, path...)
+}
+
+// podTemplateLabels returns the labels pods of a workload are created with
+func (r Resource) podTemplateLabels() map[string]string {
+	kind, _ := r.Kind()
+	path, ok := podSpecPaths[kind]
+	if !ok {
+		return nil
+	}
+
+	metadata, ok := nestedMap(r, append(slices.Clone(path[:len(path)-1]), "metadata")...)
+	if !ok {
+		return nil
+	}
+
+	return Resource{"metadata": metadata}.metadataStrings("labels")
+}
+
+// podContainers returns the containers listed under the given keys of a workload's pod spec
+func (r Resource) podContainers(keys ...string) []map[string]any {
+	spec, ok := r.podSpec()
+	if !ok {
+		return nil
+	}
+
+	containers := make([]map[string]any, 0)
+	for _, key := range keys {
+		list, _ := spec[key].([]any)
+		for _, c := range list {
+			if m, ok := asMap(c); ok {
+				containers = append(containers, m)
+			}
+		}
+	}
+
+	return containers
+}
+
+func imageInventory(resources []Resource) []ImageUsage {
+	users := make(map[string][]string)
+	for _, r := range resources {
+		for _, c := range r.containers() {
+			image, ok := c["image"].(string)
+			if !ok || image == "" {
+				continue
+			}
+
+			ref := r.Ref()
+			if !slices.Contains(users[image], ref) {
+				users[image] = append(users[image], ref)
+			}
+		}
+	}
+
+	usages := make([]ImageUsage, 0, len(users))
+	for image, refs := range users {
+		slices.Sort(refs)
+		usages = append(usages, ImageUsage{Image: image, Resources: refs})
+	}
+	slices.SortFunc(usages, func(a, b ImageUsage) int {
+		return strings.Compare(a.Image, b.Image)
+	})
+
+	return usages
+}
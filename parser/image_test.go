@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseImageOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    ImageOverride
+		wantErr error
+	}{
+		{
+			name:  "new name and tag",
+			input: "nginx=registry.local/nginx:1.25",
+			want:  ImageOverride{Name: "nginx", NewName: "registry.local/nginx", NewTag: "1.25"},
+		},
+		{
+			name:  "tag only",
+			input: "nginx=:1.25",
+			want:  ImageOverride{Name: "nginx", NewTag: "1.25"},
+		},
+		{
+			name:  "digest",
+			input: "nginx=nginx@sha256:abc",
+			want:  ImageOverride{Name: "nginx", NewName: "nginx", Digest: "sha256:abc"},
+		},
+		{
+			name:  "registry with port",
+			input: "localhost:5000/app=localhost:5000/app:v2",
+			want:  ImageOverride{Name: "localhost:5000/app", NewName: "localhost:5000/app", NewTag: "v2"},
+		},
+		{
+			name:    "missing replacement",
+			input:   "nginx",
+			wantErr: ErrInvalidImageOverride,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseImageOverride(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseImageOverride() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseImageOverride() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageOverrideTransform(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides []ImageOverride
+		resource  Resource
+		want      Resource
+	}{
+		{
+			name:      "deployment containers and initContainers",
+			overrides: []ImageOverride{{Name: "nginx", NewName: "mirror/nginx", NewTag: "1.25"}},
+			resource: Resource{
+				"kind": "Deployment",
+				"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+					"initContainers": []any{map[string]any{"image": "nginx"}},
+					"containers":     []any{map[string]any{"image": "nginx:1.0"}, map[string]any{"image": "busybox"}},
+				}}},
+			},
+			want: Resource{
+				"kind": "Deployment",
+				"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+					"initContainers": []any{map[string]any{"image": "mirror/nginx:1.25"}},
+					"containers":     []any{map[string]any{"image": "mirror/nginx:1.25"}, map[string]any{"image": "busybox"}},
+				}}},
+			},
+		},
+		{
+			name:      "cronjob keeps tag when only the name changes",
+			overrides: []ImageOverride{{Name: "busybox", NewName: "mirror/busybox"}},
+			resource: Resource{
+				"kind": "CronJob",
+				"spec": map[string]any{"jobTemplate": map[string]any{"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+					"containers": []any{map[string]any{"image": "busybox:1.36"}},
+				}}}}},
+			},
+			want: Resource{
+				"kind": "CronJob",
+				"spec": map[string]any{"jobTemplate": map[string]any{"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+					"containers": []any{map[string]any{"image": "mirror/busybox:1.36"}},
+				}}}}},
+			},
+		},
+		{
+			name:      "pod digest replaces tag",
+			overrides: []ImageOverride{{Name: "app", Digest: "sha256:abc"}},
+			resource: Resource{
+				"kind": "Pod",
+				"spec": map[string]any{"containers": []any{map[string]any{"image": "app:v1"}}},
+			},
+			want: Resource{
+				"kind": "Pod",
+				"spec": map[string]any{"containers": []any{map[string]any{"image": "app@sha256:abc"}}},
+			},
+		},
+		{
+			name:      "non workload is untouched",
+			overrides: []ImageOverride{{Name: "app", NewTag: "v2"}},
+			resource:  Resource{"kind": "ConfigMap", "spec": map[string]any{"containers": []any{map[string]any{"image": "app:v1"}}}},
+			want:      Resource{"kind": "ConfigMap", "spec": map[string]any{"containers": []any{map[string]any{"image": "app:v1"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ImageOverrideTransform(tt.overrides...)([]Resource{tt.resource})
+			if err != nil {
+				t.Errorf("ImageOverrideTransform() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("ImageOverrideTransform() = %v, want %v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func Test_imageInventory(t *testing.T) {
+	deployment := func(name, namespace string, images ...string) Resource {
+		containers := make([]any, 0)
+		for _, i := range images {
+			containers = append(containers, map[string]any{"image": i})
+		}
+		return Resource{
+			"kind":     "Deployment",
+			"metadata": map[string]any{"name": name, "namespace": namespace},
+			"spec":     map[string]any{"template": map[string]any{"spec": map[string]any{"containers": containers}}},
+		}
+	}
+
+	resources := []Resource{
+		deployment("web", "prod", "nginx:1.25", "envoy:1.29"),
+		deployment("api", "prod", "envoy:1.29", "envoy:1.29"),
+		{"kind": "Service", "metadata": map[string]any{"name": "web"}},
+	}
+
+	want := []ImageUsage{
+		{Image: "envoy:1.29", Resources: []string{"Deployment/prod/api", "Deployment/prod/web"}},
+		{Image: "nginx:1.25", Resources: []string{"Deployment/prod/web"}},
+	}
+
+	if got := imageInventory(resources); !reflect.DeepEqual(got, want) {
+		t.Errorf("imageInventory() = %v, want %v", got, want)
+	}
+}
+
+func Test_imageInventory_decodedYaml(t *testing.T) {
+	input := `kind: StatefulSet
+apiVersion: apps/v1
+metadata:
+  name: db
+spec:
+  template:
+    spec:
+      containers:
+        - name: postgres
+          image: postgres:16`
+
+	want := []ImageUsage{
+		{Image: "postgres:16", Resources: []string{"StatefulSet/db"}},
+	}
+
+	if got := imageInventory(readResource(strings.NewReader(input))); !reflect.DeepEqual(got, want) {
+		t.Errorf("imageInventory() = %v, want %v", got, want)
+	}
+}
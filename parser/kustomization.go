@@ -0,0 +1,409 @@
+package parser
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/kdwils/splinter/pkg/fio"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultKustomizationFile = "kustomization.yaml"
+
+var (
+	ErrInvalidKustomizationFile = errors.New("kustomization file must be kustomization.yaml, kustomization.yml or Kustomization")
+	ErrInvalidKustomization     = errors.New("kustomization is not a yaml mapping")
+)
+
+// kustomizationFiles are the file names kustomize looks for, in the order it looks for them
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// KustomizationOptions sets fields of the kustomizations generated by split
+type KustomizationOptions struct {
+	// FileName is kustomization.yaml, kustomization.yml or Kustomization, defaulting to kustomization.yaml
+	FileName          string
+	Namespace         string
+	NamePrefix        string
+	NameSuffix        string
+	Labels            map[string]string
+	CommonAnnotations map[string]string
+	Images            []ImageOverride
+	// HoistNamespace removes the namespace from the resources of a kustomization when they all share it and sets
+	// it on the kustomization instead
+	HoistNamespace bool
+}
+
+// WithKustomization sets the options of the kustomizations generated by split
+func WithKustomization(opts KustomizationOptions) ParserOpt {
+	return func(p *Parser) {
+		p.kustomization = opts
+	}
+}
+
+// kustomizationFile returns the file name kustomizations are written to
+func (p *Parser) kustomizationFile() (string, error) {
+	name := cmp.Or(p.kustomization.FileName, defaultKustomizationFile)
+	if !slices.Contains(kustomizationFiles, name) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKustomizationFile, name)
+	}
+	return name, nil
+}
+
+// applyKustomizationOptions sets the configured fields on a kustomization listing resources, hoisting their
+// namespace when enabled
+func (p *Parser) applyKustomizationOptions(k Resource, resources []Resource, clusterKinds map[string]bool) {
+	opts := p.kustomization
+
+	namespace := opts.Namespace
+	if opts.HoistNamespace {
+		if common := commonNamespace(resources, clusterKinds); common != "" && (namespace == "" || namespace == common) {
+			namespace = common
+			for _, r := range resources {
+				// the namespace of an encrypted resource is covered by its message authentication code
+				if isEncrypted(r) {
+					continue
+				}
+				if metadata, ok := nestedMap(r, "metadata"); ok {
+					delete(metadata, "namespace")
+				}
+			}
+		}
+	}
+
+	if namespace != "" {
+		k["namespace"] = namespace
+	}
+	if opts.NamePrefix != "" {
+		k["namePrefix"] = opts.NamePrefix
+	}
+	if opts.NameSuffix != "" {
+		k["nameSuffix"] = opts.NameSuffix
+	}
+	if len(opts.Labels) > 0 {
+		k["labels"] = []any{Resource{"pairs": opts.Labels}}
+	}
+	if len(opts.CommonAnnotations) > 0 {
+		k["commonAnnotations"] = opts.CommonAnnotations
+	}
+	if len(opts.Images) > 0 {
+		images := make([]any, 0, len(opts.Images))
+		for _, o := range opts.Images {
+			image := Resource{"name": o.Name}
+			if o.NewName != "" {
+				image["newName"] = o.NewName
+			}
+			if o.NewTag != "" {
+				image["newTag"] = o.NewTag
+			}
+			if o.Digest != "" {
+				image["digest"] = o.Digest
+			}
+			images = append(images, image)
+		}
+		k["images"] = images
+	}
+}
+
+// commonNamespace returns the namespace shared by every namespaced resource, or an empty string when a namespaced
+// resource has none, they differ or one is encrypted. Kustomize would move resources without a namespace into the
+// hoisted one, and would set it on encrypted resources it cannot be removed from.
+func commonNamespace(resources []Resource, clusterKinds map[string]bool) string {
+	namespace := ""
+	for _, r := range resources {
+		if isEncrypted(r) {
+			return ""
+		}
+
+		kind, _ := r.Kind()
+		if clusterScopedKinds[kind] || clusterKinds[kind] {
+			continue
+		}
+
+		ns := r.Namespace()
+		switch {
+		case ns == "":
+			return ""
+		case namespace == "":
+			namespace = ns
+		case namespace != ns:
+			return ""
+		}
+	}
+	return namespace
+}
+
+// WithKustomizationUpdate updates kustomizations already present in the output on split instead of overwriting them.
+// The resources list, the configMapGenerator and secretGenerator entries and the namespace are reconciled; every
+// other field and comment is kept. Files the lock of an earlier split records, that it no longer writes, are removed.
+func WithKustomizationUpdate(update bool) ParserOpt {
+	return func(p *Parser) {
+		p.updateKustomization = update
+	}
+}
+
+// updateKustomizations writes the resources, generators and namespace of the generated kustomizations into the
+// kustomizations already in the output and removes the generated ones from files. Generated kustomizations without an existing file are kept.
+func (p *Parser) updateKustomizations(outputPath string, files map[string][]Resource) error {
+	fileName, err := p.kustomizationFile()
+	if err != nil {
+		return err
+	}
+	var owned map[string]string
+	for name, rs := range files {
+		if path.Base(name) != fileName || len(rs) == 0 {
+			continue
+		}
+
+		dir := path.Join(outputPath, path.Dir(name))
+		existing := p.existingKustomization(dir)
+		if existing == "" {
+			continue
+		}
+
+		b, err := p.fio.ReadFile(existing)
+		if err != nil {
+			return err
+		}
+		if owned == nil {
+			owned = p.ownedFiles(outputPath)
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return fmt.Errorf("%s: %w", existing, err)
+		}
+		if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			return fmt.Errorf("%s: %w", existing, ErrInvalidKustomization)
+		}
+
+		resources, _ := rs[0]["resources"].([]string)
+		if err := p.reconcileResources(doc.Content[0], dir, resources, owned); err != nil {
+			return fmt.Errorf("%s: %w", existing, err)
+		}
+		for _, key := range []string{configMapGeneratorKey, secretGeneratorKey} {
+			generators, _ := rs[0][key].([]generator)
+			if err := p.reconcileGenerators(doc.Content[0], dir, key, generators); err != nil {
+				return fmt.Errorf("%s: %w", existing, err)
+			}
+		}
+		if namespace, ok := rs[0]["namespace"].(string); ok {
+			setScalar(doc.Content[0], "namespace", namespace)
+		}
+
+		var buf bytes.Buffer
+		e := yaml.NewEncoder(&buf)
+		e.SetIndent(p.indentSize)
+		if err := e.Encode(&doc); err != nil {
+			return err
+		}
+		if err := e.Close(); err != nil {
+			return err
+		}
+
+		if err := p.fio.WriteFile(existing, buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+		delete(files, name)
+	}
+
+	return nil
+}
+
+// existingKustomization returns the path of the kustomization in dir, or an empty string when there is none
+func (p *Parser) existingKustomization(dir string) string {
+	for _, name := range kustomizationFiles {
+		f := path.Join(dir, name)
+		if _, err := p.fio.Stat(f); err == nil {
+			return f
+		}
+	}
+	return ""
+}
+
+// reconcileResources updates the resources list of a kustomization in place. Entries for files split no longer
+// writes are removed, new files are appended and every other entry keeps its position and comments.
+func (p *Parser) reconcileResources(k *yaml.Node, dir string, resources []string, owned map[string]string) error {
+	list := mappingValue(k, "resources")
+	if list == nil || list.Kind != yaml.SequenceNode {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setValue(k, "resources", list)
+	}
+
+	wanted := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		wanted[r] = true
+	}
+
+	present := make(map[string]bool, len(list.Content))
+	items := make([]*yaml.Node, 0, len(list.Content)+len(resources))
+	for _, item := range list.Content {
+		if !wanted[item.Value] {
+			stale, err := p.staleResource(dir, item.Value, owned)
+			if err != nil {
+				return err
+			}
+			if stale {
+				continue
+			}
+		}
+		present[item.Value] = true
+		items = append(items, item)
+	}
+
+	for _, r := range resources {
+		if !present[r] {
+			items = append(items, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: r})
+		}
+	}
+
+	list.Content = items
+	return nil
+}
+
+// reconcileGenerators updates a generator list of a kustomization in place. Entries with the name and namespace of a
+// generated one are replaced, entries referencing a file that no longer exists are removed and new ones are appended.
+func (p *Parser) reconcileGenerators(k *yaml.Node, dir, key string, generators []generator) error {
+	list := mappingValue(k, key)
+	if list == nil && len(generators) == 0 {
+		return nil
+	}
+	if list == nil || list.Kind != yaml.SequenceNode {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setValue(k, key, list)
+	}
+
+	generated := make(map[string]*yaml.Node, len(generators))
+	order := make([]string, 0, len(generators))
+	for _, g := range generators {
+		var n yaml.Node
+		if err := n.Encode(g); err != nil {
+			return err
+		}
+		id := g.Namespace + "/" + g.Name
+		generated[id] = &n
+		order = append(order, id)
+	}
+
+	items := make([]*yaml.Node, 0, len(list.Content)+len(generators))
+	for _, item := range list.Content {
+		var g generator
+		if err := item.Decode(&g); err != nil {
+			items = append(items, item)
+			continue
+		}
+
+		id := g.Namespace + "/" + g.Name
+		if n, ok := generated[id]; ok {
+			n.HeadComment, n.LineComment, n.FootComment = item.HeadComment, item.LineComment, item.FootComment
+			items = append(items, n)
+			delete(generated, id)
+			continue
+		}
+		if p.staleGenerator(dir, g) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	for _, id := range order {
+		if n, ok := generated[id]; ok {
+			items = append(items, n)
+		}
+	}
+
+	list.Content = items
+	return nil
+}
+
+// staleGenerator reports whether a generator entry references a file that is missing
+func (p *Parser) staleGenerator(dir string, g generator) bool {
+	for _, f := range g.Files {
+		_, name, ok := strings.Cut(f, "=")
+		if !ok {
+			name = f
+		}
+		if _, err := p.fio.Stat(path.Join(dir, name)); errors.Is(err, os.ErrNotExist) {
+			return true
+		}
+	}
+	return false
+}
+
+// mappingValue returns the value of key in a mapping node, or nil when it is not set
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setValue sets key in a mapping node, keeping its position when it is already set
+func setValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// setScalar sets key in a mapping node to a string, keeping the comments of the value it replaces
+func setScalar(m *yaml.Node, key, value string) {
+	if n := mappingValue(m, key); n != nil && n.Kind == yaml.ScalarNode {
+		n.Value, n.Tag, n.Style = value, "!!str", 0
+		return
+	}
+	setValue(m, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
+
+// staleResource reports whether a kustomization entry is a local yaml file split no longer writes: one that is
+// missing, or one the previous split wrote according to owned and that is unchanged since. The unchanged file is
+// removed along with the entry, unless the FileIO cannot remove files.
+func (p *Parser) staleResource(dir, entry string, owned map[string]string) (bool, error) {
+	ext := strings.ToLower(path.Ext(entry))
+	if strings.Contains(entry, "://") || (ext != ".yaml" && ext != ".yml") {
+		return false, nil
+	}
+
+	name := path.Join(dir, entry)
+	b, err := p.fio.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, nil
+	}
+
+	if d, ok := owned[name]; !ok || d != digest(b) {
+		return false, nil
+	}
+	err = fio.Remove(p.fio, name)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ownedFiles returns the digests of the files the lock in the output records split wrote, keyed by their path, or
+// nothing when the output has no lock
+func (p *Parser) ownedFiles(outputPath string) map[string]string {
+	lock, err := p.ReadLock(outputPath)
+	if err != nil {
+		return nil
+	}
+
+	owned := make(map[string]string, len(lock.Files))
+	for _, f := range lock.Files {
+		owned[path.Join(outputPath, f.Path)] = f.Digest
+	}
+	return owned
+}
@@ -0,0 +1,356 @@
+package parser
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/kdwils/splinter/pkg/fio"
+	"github.com/kdwils/splinter/pkg/fio/mocks"
+	"github.com/kdwils/splinter/pkg/sops"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParser_applyKustomizationOptions(t *testing.T) {
+	newResources := func(serviceNamespace string) []Resource {
+		service := Resource{"kind": "Service", "metadata": Resource{"name": "web"}}
+		if serviceNamespace != "" {
+			service["metadata"].(Resource)["namespace"] = serviceNamespace
+		}
+		return []Resource{
+			{"kind": "Namespace", "metadata": Resource{"name": "app"}},
+			{"kind": "Deployment", "metadata": Resource{"name": "web", "namespace": "app"}},
+			service,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		opts          KustomizationOptions
+		resources     []Resource
+		want          Resource
+		wantResources []Resource
+	}{
+		{
+			name:      "fields",
+			opts:      KustomizationOptions{Namespace: "prod", NamePrefix: "prod-", NameSuffix: "-v2", Labels: map[string]string{"team": "web"}, CommonAnnotations: map[string]string{"owner": "me"}, Images: []ImageOverride{{Name: "nginx", NewTag: "1.26"}}},
+			resources: newResources("app"),
+			want: Resource{
+				"namespace":         "prod",
+				"namePrefix":        "prod-",
+				"nameSuffix":        "-v2",
+				"labels":            []any{Resource{"pairs": map[string]string{"team": "web"}}},
+				"commonAnnotations": map[string]string{"owner": "me"},
+				"images":            []any{Resource{"name": "nginx", "newTag": "1.26"}},
+			},
+			wantResources: newResources("app"),
+		},
+		{
+			name:      "hoists a common namespace",
+			opts:      KustomizationOptions{HoistNamespace: true},
+			resources: newResources("app"),
+			want:      Resource{"namespace": "app"},
+			wantResources: []Resource{
+				{"kind": "Namespace", "metadata": Resource{"name": "app"}},
+				{"kind": "Deployment", "metadata": Resource{"name": "web"}},
+				{"kind": "Service", "metadata": Resource{"name": "web"}},
+			},
+		},
+		{
+			name:          "keeps namespaces when a resource has none",
+			opts:          KustomizationOptions{HoistNamespace: true},
+			resources:     newResources(""),
+			want:          Resource{},
+			wantResources: newResources(""),
+		},
+		{
+			name:          "keeps namespaces that differ from the configured one",
+			opts:          KustomizationOptions{HoistNamespace: true, Namespace: "prod"},
+			resources:     newResources("app"),
+			want:          Resource{"namespace": "prod"},
+			wantResources: newResources("app"),
+		},
+		{
+			name: "keeps namespaces when a resource is encrypted",
+			opts: KustomizationOptions{HoistNamespace: true},
+			resources: []Resource{
+				{"kind": "Deployment", "metadata": Resource{"name": "web", "namespace": "app"}},
+				{"kind": "Secret", "metadata": Resource{"name": "creds", "namespace": "app"}, "sops": Resource{"mac": "ENC[]"}},
+			},
+			want: Resource{},
+			wantResources: []Resource{
+				{"kind": "Deployment", "metadata": Resource{"name": "web", "namespace": "app"}},
+				{"kind": "Secret", "metadata": Resource{"name": "creds", "namespace": "app"}, "sops": Resource{"mac": "ENC[]"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := Resource{}
+			New(WithKustomization(tt.opts)).applyKustomizationOptions(k, tt.resources, nil)
+			if !reflect.DeepEqual(k, tt.want) {
+				t.Errorf("kustomization = %v, want %v", k, tt.want)
+			}
+			if !reflect.DeepEqual(tt.resources, tt.wantResources) {
+				t.Errorf("resources = %v, want %v", tt.resources, tt.wantResources)
+			}
+		})
+	}
+}
+
+func TestParser_Split_hoistNamespaceEncrypted(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := sops.ReadRecipients(strings.NewReader(id.Recipient().String()), sops.SecretRegex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := sops.ReadIdentities(strings.NewReader(id.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := fio.NewMemory()
+	m.AddFile("input.yaml", []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: app
+stringData:
+  password: hunter2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: app
+`))
+
+	split := New(WithFileIO(m), WithTransforms(EncryptSecretsTransform(e)), WithKustomization(KustomizationOptions{HoistNamespace: true}))
+	if err := split.Split([]string{"input.yaml"}, nil, "out", true); err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+
+	if err := New(WithFileIO(m), WithDecryption(d)).Merge([]string{"out/secret.yaml"}, nil, "merged.yaml"); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	b, err := m.ReadFile("merged.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "namespace: app") || !strings.Contains(string(b), "password: hunter2") {
+		t.Errorf("merged =\n%s\nwant the decrypted secret in namespace app", b)
+	}
+}
+
+func TestParser_kustomizationFile(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     string
+		wantErr  error
+	}{
+		{fileName: "", want: "kustomization.yaml"},
+		{fileName: "kustomization.yml", want: "kustomization.yml"},
+		{fileName: "Kustomization", want: "Kustomization"},
+		{fileName: "kustomize.yaml", wantErr: ErrInvalidKustomizationFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			got, err := New(WithKustomization(KustomizationOptions{FileName: tt.fileName})).kustomizationFile()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("kustomizationFile() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("kustomizationFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_updateKustomizations(t *testing.T) {
+	existing := `# hand maintained
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - ../common # shared
+  - configmap.yaml
+  - extra.yaml
+  - removed.yaml
+patches:
+  # keep replicas low
+  - path: patch.yaml
+`
+	want := `# hand maintained
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - ../common # shared
+  - extra.yaml
+  - deployment.yaml
+  - service.yaml
+patches:
+  # keep replicas low
+  - path: patch.yaml
+`
+
+	t.Run("reconciles resources of an existing kustomization", func(t *testing.T) {
+		configMap := []byte("kind: ConfigMap\nmetadata:\n  name: old\n")
+		m := fio.NewMemory()
+		m.AddFile("out/kustomization.yaml", []byte(existing))
+		m.AddFile("out/configmap.yaml", configMap)
+		m.AddFile("out/extra.yaml", []byte("kind: ConfigMap\nmetadata:\n  name: extra\n"))
+		m.AddFile("out/"+LockFileName, []byte("version: 1\ninputs: []\nfiles:\n  - path: configmap.yaml\n    digest: "+digest(configMap)+"\n"))
+
+		files := map[string][]Resource{
+			"kustomization.yaml": {newKustomizeResource("deployment.yaml", "service.yaml")},
+			"deployment.yaml":    {{"kind": "Deployment"}},
+		}
+
+		p := New(WithFileIO(m), WithKustomizationUpdate(true))
+		if err := p.updateKustomizations("out", files); err != nil {
+			t.Fatalf("updateKustomizations() error = %v", err)
+		}
+		if _, ok := files["kustomization.yaml"]; ok {
+			t.Errorf("generated kustomization was not removed from files")
+		}
+		if got, _ := m.Contents("out/kustomization.yaml"); got != want {
+			t.Errorf("kustomization = %s, want %s", got, want)
+		}
+		if _, err := m.Stat("out/configmap.yaml"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("configmap.yaml was not removed along with its entry: %v", err)
+		}
+	})
+
+	t.Run("keeps entries of files split did not write", func(t *testing.T) {
+		existing := "resources:\n  - configmap.yaml\n  - service.yaml\n"
+		m := fio.NewMemory()
+		m.AddFile("out/kustomization.yaml", []byte(existing))
+		m.AddFile("out/configmap.yaml", []byte("kind: ConfigMap\nmetadata:\n  name: edited\n"))
+		m.AddFile("out/service.yaml", []byte("kind: Service\nmetadata:\n  name: hand-written\n"))
+		m.AddFile("out/"+LockFileName, []byte("version: 1\ninputs: []\nfiles:\n  - path: configmap.yaml\n    digest: sha256:0\n"))
+
+		files := map[string][]Resource{"kustomization.yaml": {newKustomizeResource("deployment.yaml")}}
+		if err := New(WithFileIO(m), WithKustomizationUpdate(true)).updateKustomizations("out", files); err != nil {
+			t.Fatalf("updateKustomizations() error = %v", err)
+		}
+
+		want := "resources:\n  - configmap.yaml\n  - service.yaml\n  - deployment.yaml\n"
+		if got, _ := m.Contents("out/kustomization.yaml"); got != want {
+			t.Errorf("kustomization = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("removes files an earlier locked split wrote", func(t *testing.T) {
+		m := fio.NewMemory()
+		m.AddFile("input.yaml", []byte("kind: Service\nmetadata:\n  name: web\n---\nkind: Deployment\nmetadata:\n  name: web\n"))
+
+		p := New(WithFileIO(m), WithKustomizationUpdate(true))
+		if _, err := p.SplitLocked([]string{"input.yaml"}, nil, "out", true, nil); err != nil {
+			t.Fatalf("SplitLocked() error = %v", err)
+		}
+		m.AddFile("input.yaml", []byte("kind: Deployment\nmetadata:\n  name: web\n"))
+		lock, err := p.SplitLocked([]string{"input.yaml"}, nil, "out", true, nil)
+		if err != nil {
+			t.Fatalf("SplitLocked() error = %v", err)
+		}
+
+		if got, want := m.FilesIn("out"), []string{"deployment.yaml", "kustomization.yaml", LockFileName}; !reflect.DeepEqual(got, want) {
+			t.Errorf("output = %v, want %v", got, want)
+		}
+		if got, _ := m.Contents("out/kustomization.yaml"); strings.Contains(got, "service.yaml") {
+			t.Errorf("kustomization = %s, want no service.yaml", got)
+		}
+		if drifts, err := p.Verify(lock, "out", nil, true); err != nil || len(drifts) != 0 {
+			t.Errorf("Verify() = %v, %v, want no drift", drifts, err)
+		}
+	})
+
+	t.Run("reconciles generators and a hoisted namespace", func(t *testing.T) {
+		m := fio.NewMemory()
+		m.AddFile("input.yaml", []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: web
+data:
+  app.conf: one
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: web
+`))
+		m.AddFile("out/kustomization.yaml", []byte(`# hand maintained
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - service.yaml
+configMapGenerator:
+  # regenerated by split
+  - name: settings
+    namespace: web
+    files:
+      - configmaps/settings/app.conf
+  - name: removed
+    files:
+      - configmaps/removed/key
+  - name: manual
+    files:
+      - manual.properties
+`))
+		m.AddFile("out/manual.properties", []byte("a=b\n"))
+
+		want := `# hand maintained
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - service.yaml
+configMapGenerator:
+  # regenerated by split
+  - name: settings
+    namespace: web
+    files:
+      - configmaps/web/settings/app.conf
+    options:
+      disableNameSuffixHash: true
+  - name: manual
+    files:
+      - manual.properties
+namespace: web
+`
+
+		p := New(WithFileIO(m), WithExtractData(true), WithKustomizationUpdate(true), WithKustomization(KustomizationOptions{HoistNamespace: true}))
+		if err := p.Split([]string{"input.yaml"}, nil, "out", true); err != nil {
+			t.Fatalf("Split() error = %v", err)
+		}
+		if got, _ := m.Contents("out/kustomization.yaml"); got != want {
+			t.Errorf("kustomization = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("keeps a generated kustomization without an existing file", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFio := mocks.NewMockFileIO(ctrl)
+
+		mockFio.EXPECT().Stat(gomock.Any()).Return(nil, os.ErrNotExist).Times(len(kustomizationFiles))
+
+		files := map[string][]Resource{"kustomization.yaml": {newKustomizeResource("service.yaml")}}
+
+		p := New(WithFileIO(mockFio), WithKustomizationUpdate(true))
+		if err := p.updateKustomizations("out", files); err != nil {
+			t.Fatalf("updateKustomizations() error = %v", err)
+		}
+		if _, ok := files["kustomization.yaml"]; !ok {
+			t.Errorf("generated kustomization was removed from files")
+		}
+	})
+}
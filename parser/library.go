@@ -0,0 +1,237 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"sync"
+
+	"github.com/kdwils/splinter/pkg/fio"
+)
+
+// Stream is a named yaml stream read by Read
+type Stream struct {
+	Name   string
+	Reader io.Reader
+
+	// files holds the files next to a stream read from an archive or a git revision, in which it is named path
+	files fio.FileIO
+	path  string
+}
+
+// Source provides the yaml streams Read decodes. fileIO is the FileIO of the parser reading the source.
+type Source interface {
+	Streams(ctx context.Context, fileIO fio.FileIO) ([]Stream, error)
+}
+
+// Sink receives the files written by Write, named relative to the root of the output
+type Sink interface {
+	WriteFile(ctx context.Context, name string, data []byte) error
+}
+
+// Read reads the sources with a default parser
+func Read(ctx context.Context, sources ...Source) (ResourceSet, error) {
+	return New().Read(ctx, sources...)
+}
+
+// Write writes the set to the sink with a default parser
+func Write(ctx context.Context, set ResourceSet, sink Sink) error {
+	return New().Write(ctx, set, sink)
+}
+
+// Read decodes the sources in order and returns every resource with a kind, validated, expanded and transformed
+// as the parser is configured to do when splitting or merging
+func (p *Parser) Read(ctx context.Context, sources ...Source) (ResourceSet, error) {
+	docs := make([]document, 0)
+	for _, s := range sources {
+		if err := ctx.Err(); err != nil {
+			return ResourceSet{}, err
+		}
+
+		var streams []Stream
+		var err error
+		if files, ok := s.(filesSource); ok {
+			// files are read by this parser, so it records them when locking like split and merge do
+			streams, err = p.inputStreams(files)
+		} else {
+			streams, err = s.Streams(ctx, p.fio)
+		}
+		if err != nil {
+			return ResourceSet{}, err
+		}
+		for _, stream := range streams {
+			docs = append(docs, streamDocuments(stream)...)
+		}
+	}
+
+	if err := p.decrypt(docs); err != nil {
+		return ResourceSet{}, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return ResourceSet{}, err
+	}
+
+	resources, err := p.resources(docs)
+	if err != nil {
+		return ResourceSet{}, err
+	}
+
+	return ResourceSet{resources: resources}, nil
+}
+
+// Write writes the set to the sink in the layout split uses, one file per kind. Like split, it fails on plain Secrets
+// when configured to, and writes Secrets into the secrets directory through the parser's FileIO instead of the sink.
+func (p *Parser) Write(ctx context.Context, set ResourceSet, sink Sink) error {
+	if err := p.checkPlainSecrets(set.resources); err != nil {
+		return err
+	}
+
+	resources, secrets := p.routeSecrets(set.resources)
+	if len(secrets) > 0 {
+		if err := p.write(path.Join(p.secretsDir, "secret.yaml"), p.indentSize, secrets...); err != nil {
+			return err
+		}
+	}
+
+	files := p.groupFiles(resources)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := write(&buf, p.indentSize, files[name]...); err != nil {
+			return err
+		}
+		if err := sink.WriteFile(ctx, name, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type filesSource []string
+
+// Files is a Source reading yaml files, directories, archives and git:<rev>:<path> inputs, the way split and merge
+// read their input
+func Files(paths ...string) Source {
+	return filesSource(paths)
+}
+
+func (s filesSource) Streams(ctx context.Context, fileIO fio.FileIO) ([]Stream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return New(WithFileIO(fileIO)).inputStreams(s)
+}
+
+type readerSource Stream
+
+// Reader is a Source reading a single yaml stream, reporting positions in it under name
+func Reader(name string, r io.Reader) Source {
+	return readerSource{Name: name, Reader: r}
+}
+
+func (s readerSource) Streams(context.Context, fio.FileIO) ([]Stream, error) {
+	return []Stream{Stream(s)}, nil
+}
+
+type dirSink struct {
+	dir    string
+	fileIO fio.FileIO
+}
+
+// DirSink is a Sink writing files under dir through fileIO, or the os package when fileIO is nil
+func DirSink(dir string, fileIO fio.FileIO) Sink {
+	if fileIO == nil {
+		fileIO = fio.NewDefaultFileIO()
+	}
+	return dirSink{dir: dir, fileIO: fileIO}
+}
+
+func (s dirSink) WriteFile(_ context.Context, name string, data []byte) error {
+	return New(WithFileIO(s.fileIO)).writeFile(path.Join(s.dir, name), data)
+}
+
+type writerSink struct {
+	mu      sync.Mutex
+	w       io.Writer
+	written bool
+}
+
+// WriterSink is a Sink writing every file to w as a single yaml stream, the way merge writes its output
+func WriterSink(w io.Writer) Sink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) WriteFile(_ context.Context, _ string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.written {
+		if _, err := io.WriteString(s.w, "---\n"); err != nil {
+			return err
+		}
+	}
+	s.written = true
+
+	_, err := s.w.Write(data)
+	return err
+}
+
+// MemorySink is a Sink keeping the files it receives in memory
+type MemorySink struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemorySink returns an empty MemorySink
+func NewMemorySink() *MemorySink {
+	return &MemorySink{files: make(map[string][]byte)}
+}
+
+func (s *MemorySink) WriteFile(_ context.Context, name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[name] = slices.Clone(data)
+	return nil
+}
+
+// Files returns the names of the files written to the sink in sorted order
+func (s *MemorySink) Files() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// File returns the contents of a file written to the sink
+func (s *MemorySink) File(name string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.files[name]
+	return slices.Clone(data), ok
+}
+
+// Stdin is a Source reading os.Stdin
+func Stdin() Source {
+	return Reader(stdinSource, os.Stdin)
+}
@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kdwils/splinter/pkg/fio"
+	"github.com/kdwils/splinter/pkg/fio/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+const libraryInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: app
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: app
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+  namespace: other
+`
+
+func TestParser_Read(t *testing.T) {
+	t.Run("reads and transforms sources in order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFio := mocks.NewMockFileIO(ctrl)
+		mockFio.EXPECT().ReadFile("extra.yaml").Return([]byte("kind: ConfigMap\nmetadata:\n  name: extra\n"), nil)
+
+		p := New(WithFileIO(mockFio), WithTransforms(ImageOverrideTransform(ImageOverride{Name: "nginx", NewTag: "1.26"})))
+		set, err := p.Read(context.Background(), Reader("input", strings.NewReader(libraryInput)), Files("extra.yaml"))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+
+		refs := make([]string, 0, set.Len())
+		for _, r := range set.Resources() {
+			refs = append(refs, r.Ref())
+		}
+		want := []string{"Deployment/app/web", "Service/app/web", "Service/other/api", "ConfigMap/extra"}
+		if !reflect.DeepEqual(refs, want) {
+			t.Errorf("Read() = %v, want %v", refs, want)
+		}
+		if image := set.Resources()[0].containers()[0]["image"]; image != "nginx:1.26" {
+			t.Errorf("image = %v, want nginx:1.26", image)
+		}
+	})
+
+	t.Run("records files read by the parser", func(t *testing.T) {
+		m := fio.NewMemory()
+		m.AddFile("input.yaml", []byte(libraryInput))
+
+		p := New(WithFileIO(m))
+		p.lock = &lockRecorder{revisions: make(map[string]string)}
+		if _, err := p.Read(context.Background(), Files("input.yaml")); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(p.lock.inputs) != 1 || p.lock.inputs[0].Source != "input.yaml" {
+			t.Errorf("lock inputs = %v, want input.yaml", p.lock.inputs)
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := Read(ctx, Reader("input", strings.NewReader(libraryInput))); !errors.Is(err, context.Canceled) {
+			t.Errorf("Read() error = %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestResourceSet(t *testing.T) {
+	set, err := Read(context.Background(), Reader("input", strings.NewReader(libraryInput)))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if got := set.Filter(OfKind("Service")).Len(); got != 2 {
+		t.Errorf("Filter(OfKind) len = %d, want 2", got)
+	}
+	if got := set.Filter(InNamespace("app")).Len(); got != 2 {
+		t.Errorf("Filter(InNamespace) len = %d, want 2", got)
+	}
+
+	groups := set.Group(ByNamespace)
+	if len(groups) != 2 || groups["app"].Len() != 2 || groups["other"].Len() != 1 {
+		t.Errorf("Group(ByNamespace) = %v", groups)
+	}
+
+	dropped, err := set.Transform(func(resources []Resource) ([]Resource, error) {
+		return resources[:1], nil
+	})
+	if err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+	if dropped.Len() != 1 || set.Len() != 3 {
+		t.Errorf("Transform() len = %d, receiver len = %d, want 1 and 3", dropped.Len(), set.Len())
+	}
+
+	wantErr := errors.New("boom")
+	if _, err := set.Transform(func([]Resource) ([]Resource, error) { return nil, wantErr }); !errors.Is(err, wantErr) {
+		t.Errorf("Transform() error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestParser_Write(t *testing.T) {
+	set := NewResourceSet(
+		Resource{"kind": "Service", "metadata": Resource{"name": "web"}},
+		Resource{"kind": "CustomResourceDefinition", "metadata": Resource{"name": "foos.example.com"}},
+		Resource{"kind": "Service", "metadata": Resource{"name": "api"}},
+	)
+
+	t.Run("memory sink", func(t *testing.T) {
+		sink := NewMemorySink()
+		if err := New(WithSeparateCRDs(true)).Write(context.Background(), set, sink); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+
+		want := []string{"crds/customresourcedefinition.yaml", "service.yaml"}
+		if got := sink.Files(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Files() = %v, want %v", got, want)
+		}
+
+		got, _ := sink.File("service.yaml")
+		wantService := "kind: Service\nmetadata:\n  name: web\n---\nkind: Service\nmetadata:\n  name: api\n"
+		if string(got) != wantService {
+			t.Errorf("service.yaml =\n%s\nwant\n%s", got, wantService)
+		}
+	})
+
+	secrets := NewResourceSet(
+		Resource{"kind": "Service", "metadata": Resource{"name": "web"}},
+		Resource{"apiVersion": "v1", "kind": "Secret", "metadata": Resource{"name": "creds"}, "stringData": Resource{"password": "hunter2"}},
+	)
+
+	t.Run("fails on plain secrets", func(t *testing.T) {
+		sink := NewMemorySink()
+		if err := New(WithFailOnSecrets(true)).Write(context.Background(), secrets, sink); !errors.Is(err, ErrPlainSecret) {
+			t.Errorf("Write() error = %v, want %v", err, ErrPlainSecret)
+		}
+		if got := sink.Files(); len(got) != 0 {
+			t.Errorf("Files() = %v, want none", got)
+		}
+	})
+
+	t.Run("writes secrets into the secrets directory", func(t *testing.T) {
+		m := fio.NewMemory()
+		sink := NewMemorySink()
+		if err := New(WithFileIO(m), WithSecretsDir("secrets")).Write(context.Background(), secrets, sink); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+
+		if got, want := sink.Files(), []string{"service.yaml"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Files() = %v, want %v", got, want)
+		}
+		if got, _ := m.Contents("secrets/secret.yaml"); !strings.Contains(got, "password: hunter2") {
+			t.Errorf("secrets/secret.yaml = %q, want the secret", got)
+		}
+	})
+
+	t.Run("writer sink", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := Write(context.Background(), set, WriterSink(&out)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+
+		want := "kind: CustomResourceDefinition\nmetadata:\n  name: foos.example.com\n---\nkind: Service\nmetadata:\n  name: web\n---\nkind: Service\nmetadata:\n  name: api\n"
+		if out.String() != want {
+			t.Errorf("Write() =\n%s\nwant\n%s", out.String(), want)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"io"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Rule checks a single resource. The full resource set is passed for rules that look across resources.
+type Rule interface {
+	ID() string
+	Description() string
+	Severity() string
+	Check(r Resource, resources []Resource) []string
+}
+
+// Finding is a rule violation reported for a resource
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Source   string `json:"source"`
+	Line     int    `json:"line"`
+	Resource string `json:"resource"`
+	Message  string `json:"message"`
+}
+
+type rule struct {
+	id          string
+	description string
+	severity    string
+	check       func(r Resource, resources []Resource) []string
+}
+
+// NewRule returns a Rule that reports every message returned by check
+func NewRule(id, description, severity string, check func(r Resource, resources []Resource) []string) Rule {
+	return rule{
+		id:          id,
+		description: description,
+		severity:    severity,
+		check:       check,
+	}
+}
+
+func (r rule) ID() string          { return r.id }
+func (r rule) Description() string { return r.description }
+func (r rule) Severity() string    { return r.severity }
+
+func (r rule) Check(resource Resource, resources []Resource) []string {
+	return r.check(resource, resources)
+}
+
+// Lint reads the input and runs every rule against each resource
+func (p *Parser) Lint(inputFiles []string, stdin io.Reader, rules ...Rule) ([]Finding, error) {
+	docs, err := p.readDocuments(inputFiles, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]Resource, 0, len(docs))
+	for _, d := range docs {
+		if _, err := d.resource.Kind(); err == nil {
+			resources = append(resources, d.resource)
+		}
+	}
+
+	return lint(docs, resources, rules), nil
+}
+
+// lint runs the rules against every document. resources is the set rules look across, which may include
+// resources generated while reading that have no document of their own.
+func lint(docs []document, resources []Resource, rules []Rule) []Finding {
+	findings := make([]Finding, 0)
+	for _, d := range docs {
+		if _, err := d.resource.Kind(); err != nil {
+			continue
+		}
+
+		for _, rule := range rules {
+			for _, msg := range rule.Check(d.resource, resources) {
+				findings = append(findings, Finding{
+					Rule:     rule.ID(),
+					Severity: rule.Severity(),
+					Source:   d.source,
+					Line:     d.line(),
+					Resource: d.resource.Ref(),
+					Message:  msg,
+				})
+			}
+		}
+	}
+
+	return findings
+}
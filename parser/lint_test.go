@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRules(t *testing.T) {
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      volumes:
+        - name: run
+          hostPath:
+            path: /var/run
+      containers:
+        - name: web
+          image: nginx:latest
+          securityContext:
+            privileged: true
+          resources:
+            requests:
+              cpu: 100m
+          readinessProbe:
+            httpGet:
+              port: 80
+`
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Finding
+	}{
+		{
+			name:  "workload rules",
+			input: deployment,
+			want: []Finding{
+				{Rule: "resources-missing", Severity: SeverityWarning, Source: "input.yaml", Line: 1, Resource: "Deployment/web", Message: `container "web" has no resource limits`},
+				{Rule: "latest-tag", Severity: SeverityWarning, Source: "input.yaml", Line: 1, Resource: "Deployment/web", Message: `container "web" uses image "nginx:latest" without a pinned tag`},
+				{Rule: "probes-missing", Severity: SeverityWarning, Source: "input.yaml", Line: 1, Resource: "Deployment/web", Message: `container "web" has no livenessProbe`},
+				{Rule: "privileged", Severity: SeverityError, Source: "input.yaml", Line: 1, Resource: "Deployment/web", Message: `container "web" runs privileged`},
+				{Rule: "host-path", Severity: SeverityError, Source: "input.yaml", Line: 1, Resource: "Deployment/web", Message: `volume "run" mounts host path /var/run`},
+			},
+		},
+		{
+			name: "service selectors",
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: orphan
+spec:
+  selector:
+    app: api
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  containers:
+    - name: web
+      image: nginx@sha256:abc
+      resources:
+        requests:
+          cpu: 100m
+        limits:
+          memory: 64Mi
+`,
+			want: []Finding{
+				{Rule: "service-selector", Severity: SeverityWarning, Source: "input.yaml", Line: 9, Resource: "Service/orphan", Message: "selector map[app:api] matches no workload in the manifest set"},
+			},
+		},
+		{
+			name: "references outside the manifest set",
+			input: `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: viewers
+roleRef:
+  kind: ClusterRole
+  name: view
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: web
+roleRef:
+  kind: Role
+  name: web
+`,
+			want: []Finding{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs := readDocuments("input.yaml", strings.NewReader(tt.input))
+			got := lint(docs, readResource(strings.NewReader(tt.input)), DefaultRules())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lint() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRule(t *testing.T) {
+	r := NewRule("team-label", "resources need a team label", SeverityError, func(r Resource, _ []Resource) []string {
+		if r.metadataStrings("labels")["team"] == "" {
+			return []string{"missing team label"}
+		}
+		return nil
+	})
+
+	docs := readDocuments("input.yaml", strings.NewReader("kind: ConfigMap\nmetadata:\n  name: a\n"))
+	want := []Finding{
+		{Rule: "team-label", Severity: SeverityError, Source: "input.yaml", Line: 1, Resource: "ConfigMap/a", Message: "missing team label"},
+	}
+
+	if got := lint(docs, []Resource{docs[0].resource}, []Rule{r}); !reflect.DeepEqual(got, want) {
+		t.Errorf("lint() = %+v, want %+v", got, want)
+	}
+}
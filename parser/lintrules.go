@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+)
+
+// longRunningKinds are the workloads expected to define liveness and readiness probes
+var longRunningKinds = []string{"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet"}
+
+// DefaultRules returns every built-in lint rule
+func DefaultRules() []Rule {
+	return []Rule{
+		NewRule("resources-missing", "containers should set resource requests and limits", SeverityWarning, checkResources),
+		NewRule("latest-tag", "images should be pinned to a tag other than latest or to a digest", SeverityWarning, checkLatestTag),
+		NewRule("probes-missing", "long running containers should define liveness and readiness probes", SeverityWarning, checkProbes),
+		NewRule("privileged", "containers should not run privileged", SeverityError, checkPrivileged),
+		NewRule("host-path", "pods should not mount hostPath volumes", SeverityError, checkHostPath),
+		NewRule("service-selector", "service selectors should match a workload in the manifest set", SeverityWarning, checkServiceSelector),
+	}
+}
+
+// OptionalRules returns the built-in lint rules that only run when enabled in the config. References to objects
+// managed outside the manifest set are common, so these would fail most inputs.
+func OptionalRules() []Rule {
+	return []Rule{DanglingReferenceRule()}
+}
+
+func containerName(c map[string]any) string {
+	name, _ := c["name"].(string)
+	return name
+}
+
+func checkResources(r Resource, _ []Resource) []string {
+	msgs := make([]string, 0)
+	for _, c := range r.containers() {
+		resources, _ := nestedMap(c, "resources")
+		for _, key := range []string{"requests", "limits"} {
+			if values, ok := nestedMap(resources, key); !ok || len(values) == 0 {
+				msgs = append(msgs, fmt.Sprintf("container %q has no resource %s", containerName(c), key))
+			}
+		}
+	}
+	return msgs
+}
+
+func checkLatestTag(r Resource, _ []Resource) []string {
+	msgs := make([]string, 0)
+	for _, c := range r.containers() {
+		image, ok := c["image"].(string)
+		if !ok {
+			continue
+		}
+
+		_, tag, digest := splitImage(image)
+		if digest == "" && (tag == "" || tag == "latest") {
+			msgs = append(msgs, fmt.Sprintf("container %q uses image %q without a pinned tag", containerName(c), image))
+		}
+	}
+	return msgs
+}
+
+func checkProbes(r Resource, _ []Resource) []string {
+	if kind, _ := r.Kind(); !slices.Contains(longRunningKinds, kind) {
+		return nil
+	}
+
+	msgs := make([]string, 0)
+	for _, c := range r.podContainers("containers") {
+		for _, probe := range []string{"livenessProbe", "readinessProbe"} {
+			if c[probe] == nil {
+				msgs = append(msgs, fmt.Sprintf("container %q has no %s", containerName(c), probe))
+			}
+		}
+	}
+	return msgs
+}
+
+func checkPrivileged(r Resource, _ []Resource) []string {
+	msgs := make([]string, 0)
+	for _, c := range r.containers() {
+		if privileged, _ := nestedMap(c, "securityContext"); privileged["privileged"] == true {
+			msgs = append(msgs, fmt.Sprintf("container %q runs privileged", containerName(c)))
+		}
+	}
+	return msgs
+}
+
+func checkHostPath(r Resource, _ []Resource) []string {
+	spec, ok := r.podSpec()
+	if !ok {
+		return nil
+	}
+
+	msgs := make([]string, 0)
+	volumes, _ := spec["volumes"].([]any)
+	for _, v := range volumes {
+		volume, _ := asMap(v)
+		hostPath, ok := nestedMap(volume, "hostPath")
+		if !ok {
+			continue
+		}
+		msgs = append(msgs, fmt.Sprintf("volume %q mounts host path %v", volume["name"], hostPath["path"]))
+	}
+	return msgs
+}
+
+func checkServiceSelector(r Resource, resources []Resource) []string {
+	if kind, _ := r.Kind(); kind != "Service" {
+		return nil
+	}
+
+	selector, ok := nestedMap(r, "spec", "selector")
+	if !ok || len(selector) == 0 {
+		return nil
+	}
+
+	for _, w := range resources {
+		if w.Namespace() != r.Namespace() {
+			continue
+		}
+		if labels := w.podTemplateLabels(); len(labels) > 0 && matchesSelector(labels, selector) {
+			return nil
+		}
+	}
+
+	return []string{fmt.Sprintf("selector %v matches no workload in the manifest set", selector)}
+}
+
+func matchesSelector(labels map[string]string, selector map[string]any) bool {
+	for k, v := range selector {
+		if labels[k] != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,344 @@
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kdwils/splinter/pkg/fio"
+	"github.com/kdwils/splinter/pkg/sops"
+)
+
+const (
+	LockFileName = "splinter.lock"
+	lockVersion  = 1
+
+	// DriftInput is an input whose digest differs from the lock
+	DriftInput = "input changed"
+	// DriftStale is a file the split no longer produces with the checksum in the lock
+	DriftStale = "stale"
+	// DriftModified is a file whose contents on disk differ from the lock
+	DriftModified = "modified"
+	// DriftMissing is a file in the lock missing on disk
+	DriftMissing = "missing"
+)
+
+var (
+	ErrInvalidLock    = errors.New("invalid lock")
+	ErrLockNeedsStdin = errors.New("the lock records stdin as an input, pipe the same input to verify")
+)
+
+// Lock records how split produced an output directory: the digest of every input, the options it ran with and the
+// checksum of every file it wrote
+type Lock struct {
+	Version int `yaml:"version"`
+	// Options are the split flags, leaving out the input and output
+	Options []string    `yaml:"options,omitempty"`
+	Inputs  []LockInput `yaml:"inputs"`
+	Files   []LockFile  `yaml:"files"`
+}
+
+// LockInput is an input of a split: a file, directory, archive, URL, git revision or stdin
+type LockInput struct {
+	Source string `yaml:"source"`
+	// Revision is the commit a git input resolved to
+	Revision string `yaml:"revision,omitempty"`
+	// Digest is the sha256 of the names and contents of every file read for the input
+	Digest string `yaml:"digest"`
+}
+
+// LockFile is a file written by a split, relative to the output directory
+type LockFile struct {
+	Path   string `yaml:"path"`
+	Digest string `yaml:"digest"`
+}
+
+// Drift is a difference between a lock and the inputs or the output it describes
+type Drift struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+func (d Drift) String() string {
+	return d.Reason + ": " + d.Path
+}
+
+// SplitLocked splits like Split, then writes a splinter.lock into the output directory describing the split. options
+// are recorded as given so a verify can run the same split again.
+func (p *Parser) SplitLocked(inputFiles []string, stdin io.Reader, outputPath string, kustomize bool, options []string) (Lock, error) {
+	lock, err := p.splitLock(inputFiles, stdin, outputPath, kustomize, p.fio)
+	if err != nil {
+		return Lock{}, err
+	}
+	lock.Options = options
+
+	var buf bytes.Buffer
+	e := yaml.NewEncoder(&buf)
+	e.SetIndent(p.indentSize)
+	if err := e.Encode(lock); err != nil {
+		return Lock{}, err
+	}
+	if err := e.Close(); err != nil {
+		return Lock{}, err
+	}
+
+	return lock, p.writeFile(path.Join(outputPath, LockFileName), buf.Bytes())
+}
+
+// ReadLock reads the splinter.lock of an output directory
+func (p *Parser) ReadLock(outputPath string) (Lock, error) {
+	b, err := p.fio.ReadFile(path.Join(outputPath, LockFileName))
+	if err != nil {
+		return Lock{}, err
+	}
+
+	var lock Lock
+	if err := yaml.Unmarshal(b, &lock); err != nil {
+		return Lock{}, fmt.Errorf("%w: %w", ErrInvalidLock, err)
+	}
+	if lock.Version != lockVersion {
+		return Lock{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidLock, lock.Version)
+	}
+	return lock, nil
+}
+
+// Verify runs the split a lock describes again in memory and returns every drift from the lock: inputs whose
+// digest changed, files the split no longer produces as the lock says, and files modified or missing on disk. The
+// parser must be configured with the lock's options. stdin is only read when the lock records it as an input.
+//
+// Encryption produces different output on every run, so files holding sops documents are only checked against the
+// disk. The input digests still catch any change to what they were encrypted from.
+func (p *Parser) Verify(lock Lock, outputPath string, stdin io.Reader, kustomize bool) ([]Drift, error) {
+	inputs := make([]string, 0, len(lock.Inputs))
+	readStdin := false
+	for _, in := range lock.Inputs {
+		if in.Source == stdinSource {
+			readStdin = true
+			continue
+		}
+		inputs = append(inputs, in.Source)
+	}
+	if !readStdin {
+		stdin = nil
+	} else if stdin == nil {
+		return nil, ErrLockNeedsStdin
+	}
+
+	out := fio.NewMemory()
+	predicted, err := p.splitLock(inputs, stdin, outputPath, kustomize, outputFileIO{FileIO: p.fio, out: out})
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := make([]Drift, 0)
+	for _, in := range lock.Inputs {
+		i := slices.IndexFunc(predicted.Inputs, func(p LockInput) bool { return p.Source == in.Source })
+		if i < 0 || predicted.Inputs[i].Digest != in.Digest {
+			drifts = append(drifts, Drift{Path: in.Source, Reason: DriftInput})
+		}
+	}
+
+	locked := lockDigests(lock.Files)
+	regenerated := lockDigests(predicted.Files)
+	for _, name := range unionKeys(locked, regenerated) {
+		if locked[name] == regenerated[name] {
+			continue
+		}
+		if b, err := out.ReadFile(path.Join(outputPath, name)); err == nil && locked[name] != "" && hasEncryptedDocument(b) {
+			continue
+		}
+		drifts = append(drifts, Drift{Path: name, Reason: DriftStale})
+	}
+
+	for _, f := range lock.Files {
+		b, err := p.fio.ReadFile(path.Join(outputPath, f.Path))
+		if errors.Is(err, fs.ErrNotExist) {
+			drifts = append(drifts, Drift{Path: f.Path, Reason: DriftMissing})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if digest(b) != f.Digest {
+			drifts = append(drifts, Drift{Path: f.Path, Reason: DriftModified})
+		}
+	}
+
+	return drifts, nil
+}
+
+// splitLock splits through fileIO, recording the inputs read and the files written
+func (p *Parser) splitLock(inputFiles []string, stdin io.Reader, outputPath string, kustomize bool, fileIO fio.FileIO) (Lock, error) {
+	out := &recordingFileIO{FileIO: fileIO, files: make(map[string]string)}
+	lp := *p
+	lp.fio = out
+	lp.lock = &lockRecorder{revisions: make(map[string]string)}
+
+	if err := lp.Split(inputFiles, stdin, outputPath, kustomize); err != nil {
+		return Lock{}, err
+	}
+
+	files := make([]LockFile, 0, len(out.files))
+	for name, d := range out.files {
+		rel, err := filepath.Rel(outputPath, name)
+		if err != nil {
+			return Lock{}, err
+		}
+		files = append(files, LockFile{Path: filepath.ToSlash(rel), Digest: d})
+	}
+	slices.SortFunc(files, func(a, b LockFile) int { return strings.Compare(a.Path, b.Path) })
+
+	return Lock{Version: lockVersion, Inputs: lp.lock.inputs, Files: files}, nil
+}
+
+func lockDigests(files []LockFile) map[string]string {
+	m := make(map[string]string, len(files))
+	for _, f := range files {
+		m[f.Path] = f.Digest
+	}
+	return m
+}
+
+func unionKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// hasEncryptedDocument reports whether any document of a yaml stream is encrypted with sops
+func hasEncryptedDocument(b []byte) bool {
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		var doc yaml.Node
+		if err := d.Decode(&doc); err != nil {
+			return false
+		}
+		if sops.IsEncrypted(&doc) {
+			return true
+		}
+	}
+}
+
+func digest(b []byte) string {
+	s := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(s[:])
+}
+
+// lockRecorder collects the digests of the inputs of a split
+type lockRecorder struct {
+	mu        sync.Mutex
+	inputs    []LockInput
+	revisions map[string]string
+}
+
+// record digests the streams read for an input, replacing their readers with readers over the bytes it read
+func (l *lockRecorder) record(source string, streams []Stream) error {
+	h := sha256.New()
+	for i, s := range streams {
+		b, err := io.ReadAll(s.Reader)
+		if err != nil {
+			return err
+		}
+		streams[i].Reader = bytes.NewReader(b)
+
+		fmt.Fprintf(h, "%s\x00%d\x00", s.Name, len(b))
+		h.Write(b)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inputs = append(l.inputs, LockInput{
+		Source:   source,
+		Revision: l.revisions[source],
+		Digest:   "sha256:" + hex.EncodeToString(h.Sum(nil)),
+	})
+	return nil
+}
+
+// revision records the commit a git input resolved to
+func (l *lockRecorder) revision(source, commit string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.revisions[source] = commit
+}
+
+// recordingFileIO records the checksum of every file written through it
+type recordingFileIO struct {
+	fio.FileIO
+	mu    sync.Mutex
+	files map[string]string
+}
+
+func (r *recordingFileIO) Create(name string) (io.WriteCloser, error) {
+	w, err := r.FileIO.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingWriter{WriteCloser: w, h: sha256.New(), name: name, r: r}, nil
+}
+
+func (r *recordingFileIO) WriteFile(filename string, data []byte, perm fs.FileMode) error {
+	if err := r.FileIO.WriteFile(filename, data, perm); err != nil {
+		return err
+	}
+	r.set(filename, digest(data))
+	return nil
+}
+
+func (r *recordingFileIO) Remove(name string) error {
+	if err := fio.Remove(r.FileIO, name); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.files, path.Clean(filepath.ToSlash(name)))
+	return nil
+}
+
+func (r *recordingFileIO) set(name, d string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files[path.Clean(filepath.ToSlash(name))] = d
+}
+
+type recordingWriter struct {
+	io.WriteCloser
+	h    hash.Hash
+	name string
+	r    *recordingFileIO
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	n, err := w.WriteCloser.Write(b)
+	w.h.Write(b[:n])
+	return n, err
+}
+
+func (w *recordingWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	w.r.set(w.name, "sha256:"+hex.EncodeToString(w.h.Sum(nil)))
+	return nil
+}
@@ -0,0 +1,202 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/kdwils/splinter/pkg/fio"
+	"github.com/kdwils/splinter/pkg/sops"
+)
+
+func TestParser_SplitLocked(t *testing.T) {
+	newInput := func() *fio.Memory {
+		m := fio.NewMemory()
+		m.AddFile("in/app.yaml", []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+		m.MkdirAll("out", 0o755)
+		return m
+	}
+
+	t.Run("writes the lock", func(t *testing.T) {
+		m := newInput()
+		p := New(WithFileIO(m))
+
+		lock, err := p.SplitLocked([]string{"in"}, strings.NewReader("kind: Namespace\nmetadata:\n  name: web\n"), "out", true, []string{"--kustomize=true"})
+		if err != nil {
+			t.Fatalf("SplitLocked() error = %v", err)
+		}
+
+		read, err := p.ReadLock("out")
+		if err != nil {
+			t.Fatalf("ReadLock() error = %v", err)
+		}
+		if !reflect.DeepEqual(read, lock) {
+			t.Errorf("ReadLock() = %+v, want %+v", read, lock)
+		}
+
+		sources := make([]string, 0)
+		for _, in := range lock.Inputs {
+			sources = append(sources, in.Source)
+		}
+		if want := []string{stdinSource, "in"}; !reflect.DeepEqual(sources, want) {
+			t.Errorf("inputs = %v, want %v", sources, want)
+		}
+
+		files := make([]string, 0)
+		for _, f := range lock.Files {
+			b, _ := m.ReadFile("out/" + f.Path)
+			if digest(b) != f.Digest {
+				t.Errorf("%s digest = %s, want %s", f.Path, f.Digest, digest(b))
+			}
+			files = append(files, f.Path)
+		}
+		if want := []string{"deployment.yaml", "kustomization.yaml", "namespace.yaml", "service.yaml"}; !reflect.DeepEqual(files, want) {
+			t.Errorf("files = %v, want %v", files, want)
+		}
+
+		if !reflect.DeepEqual(lock.Options, []string{"--kustomize=true"}) {
+			t.Errorf("options = %v, want --kustomize=true", lock.Options)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		change  func(m *fio.Memory)
+		want    []Drift
+		wantErr error
+	}{
+		{
+			name:   "unchanged",
+			change: func(m *fio.Memory) {},
+			want:   []Drift{},
+		},
+		{
+			name: "output modified",
+			change: func(m *fio.Memory) {
+				m.AddFile("out/deployment.yaml", []byte("kind: Deployment\n"))
+				m.AddFile("out/kustomization.yaml", nil)
+			},
+			want: []Drift{
+				{Path: "deployment.yaml", Reason: DriftModified},
+				{Path: "kustomization.yaml", Reason: DriftModified},
+			},
+		},
+		{
+			name: "input changed",
+			change: func(m *fio.Memory) {
+				m.AddFile("in/app.yaml", []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`))
+			},
+			want: []Drift{
+				{Path: "in", Reason: DriftInput},
+				{Path: "kustomization.yaml", Reason: DriftStale},
+				{Path: "service.yaml", Reason: DriftStale},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newInput()
+			p := New(WithFileIO(m))
+			lock, err := p.SplitLocked([]string{"in"}, nil, "out", true, nil)
+			if err != nil {
+				t.Fatalf("SplitLocked() error = %v", err)
+			}
+
+			tt.change(m)
+
+			got, err := p.Verify(lock, "out", nil, true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("stdin", func(t *testing.T) {
+		m := newInput()
+		p := New(WithFileIO(m))
+		stdin := "kind: Namespace\nmetadata:\n  name: web\n"
+		lock, err := p.SplitLocked(nil, strings.NewReader(stdin), "out", false, nil)
+		if err != nil {
+			t.Fatalf("SplitLocked() error = %v", err)
+		}
+
+		if _, err := p.Verify(lock, "out", nil, false); !errors.Is(err, ErrLockNeedsStdin) {
+			t.Errorf("Verify() without stdin error = %v, want %v", err, ErrLockNeedsStdin)
+		}
+		drifts, err := p.Verify(lock, "out", bytes.NewBufferString(stdin), false)
+		if err != nil || len(drifts) != 0 {
+			t.Errorf("Verify() = %v, %v, want no drift", drifts, err)
+		}
+	})
+}
+
+func TestParser_Verify_encrypted(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := sops.ReadRecipients(strings.NewReader(id.Recipient().String()), sops.SecretRegex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := fio.NewMemory()
+	m.AddFile("in/app.yaml", []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+stringData:
+  password: hunter2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+
+	p := New(WithFileIO(m), WithTransforms(EncryptSecretsTransform(e)))
+	lock, err := p.SplitLocked([]string{"in"}, nil, "out", true, nil)
+	if err != nil {
+		t.Fatalf("SplitLocked() error = %v", err)
+	}
+
+	drifts, err := p.Verify(lock, "out", nil, true)
+	if err != nil || len(drifts) != 0 {
+		t.Errorf("Verify() = %v, %v, want no drift", drifts, err)
+	}
+
+	m.AddFile("out/secret.yaml", []byte("kind: Secret\n"))
+	drifts, err = p.Verify(lock, "out", nil, true)
+	if want := []Drift{{Path: "secret.yaml", Reason: DriftModified}}; err != nil || !reflect.DeepEqual(drifts, want) {
+		t.Errorf("Verify() = %v, %v, want %v", drifts, err, want)
+	}
+}
+
+func TestParser_ReadLock(t *testing.T) {
+	m := fio.NewMemory()
+	m.AddFile("out/"+LockFileName, []byte("version: 2\n"))
+
+	if _, err := New(WithFileIO(m)).ReadLock("out"); !errors.Is(err, ErrInvalidLock) {
+		t.Errorf("ReadLock() error = %v, want %v", err, ErrInvalidLock)
+	}
+}
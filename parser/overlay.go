@@ -0,0 +1,523 @@
+package parser
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	PatchFormatStrategic = "strategic"
+	PatchFormatJSON6902  = "json6902"
+
+	overlayBaseDir     = "base"
+	overlaysDir        = "overlays"
+	overlayPatchesDir  = "patches"
+	defaultOverlayName = "variant"
+)
+
+var (
+	ErrInvalidPatchFormat = errors.New("patch format must be strategic or json6902")
+)
+
+// mergeKeys are the fields of built-in kinds whose list items strategic merge patches match by a key instead of replacing the list
+var mergeKeys = map[string]string{
+	"containers":          "name",
+	"initContainers":      "name",
+	"ephemeralContainers": "name",
+	"env":                 "name",
+	"volumes":             "name",
+	"volumeMounts":        "mountPath",
+	"volumeDevices":       "devicePath",
+	"imagePullSecrets":    "name",
+	"hostAliases":         "ip",
+	"ownerReferences":     "uid",
+}
+
+// primitiveMergeLists are the fields of built-in kinds whose values strategic merge patches add to instead of
+// replacing. Kustomize doesn't support removing values from them with a patch.
+var primitiveMergeLists = map[string]bool{
+	"finalizers": true,
+}
+
+// unpatchableLists are the fields of built-in kinds kustomize merges in ways a strategic merge patch cannot express
+// exactly, such as lists merged by more than one key or merged only by some kustomize versions
+var unpatchableLists = map[string]bool{
+	"topologySpreadConstraints": true,
+	"resourceClaims":            true,
+	"schedulingGates":           true,
+}
+
+// OverlayOptions configures the overlay written by Overlay
+type OverlayOptions struct {
+	// Name is the directory of the overlay under overlays/, defaulting to variant
+	Name string
+	// PatchFormat is strategic or json6902. Strategic merge patches fall back to json6902 for resources they cannot
+	// express exactly, such as finalizers being removed.
+	PatchFormat string
+}
+
+// Overlay writes the base resources into a base directory and an overlay under overlays/ whose kustomization
+// reproduces the variant resources: changed resources are patched, added resources are included and removed
+// resources are deleted with a patch.
+func (p *Parser) Overlay(baseFiles, variantFiles []string, outputPath string, opts OverlayOptions) error {
+	format := cmp.Or(opts.PatchFormat, PatchFormatStrategic)
+	if format != PatchFormatStrategic && format != PatchFormatJSON6902 {
+		return fmt.Errorf("%w: %q", ErrInvalidPatchFormat, format)
+	}
+
+	base, err := p.readOverlayInput(baseFiles)
+	if err != nil {
+		return err
+	}
+
+	variant, err := p.readOverlayInput(variantFiles)
+	if err != nil {
+		return err
+	}
+
+	files := p.groupFiles(base)
+	if err := p.addKustomizations(files, outputGroups(files), nil); err != nil {
+		return err
+	}
+	for name, rs := range files {
+		if err := p.write(path.Join(outputPath, overlayBaseDir, name), p.indentSize, rs...); err != nil {
+			return err
+		}
+	}
+
+	overlayDir := path.Join(overlaysDir, cmp.Or(opts.Name, defaultOverlayName))
+	rel := strings.Repeat("../", strings.Count(overlayDir, "/")+1) + overlayBaseDir
+	kustomization := newKustomizeResource(rel)
+	overlay := make(map[string][]any)
+
+	byKey := make(map[string]Resource, len(base))
+	for _, r := range base {
+		byKey[overlayKey(r)] = r
+	}
+
+	added := make([]Resource, 0)
+	patches := make([]any, 0)
+	seen := make(map[string]bool, len(variant))
+	for _, r := range variant {
+		key := overlayKey(r)
+		seen[key] = true
+
+		from, ok := byKey[key]
+		if !ok {
+			added = append(added, r)
+			continue
+		}
+		if reflect.DeepEqual(plainValue(from), plainValue(r)) {
+			continue
+		}
+
+		name := path.Join(overlayPatchesDir, patchFileName(r))
+		patch, entry := resourcePatch(from, r, format)
+		entry["path"] = name
+		overlay[name] = []any{patch}
+		patches = append(patches, entry)
+	}
+
+	for _, r := range base {
+		if seen[overlayKey(r)] {
+			continue
+		}
+		name := path.Join(overlayPatchesDir, "delete-"+patchFileName(r))
+		overlay[name] = []any{deletePatch(r)}
+		patches = append(patches, Resource{"path": name})
+	}
+
+	resources := []string{rel}
+	for name, rs := range p.groupFiles(added) {
+		for _, r := range rs {
+			overlay[name] = append(overlay[name], r)
+		}
+		resources = append(resources, name)
+	}
+	slices.Sort(resources[1:])
+	kustomization["resources"] = resources
+
+	if len(patches) > 0 {
+		slices.SortFunc(patches, func(a, b any) int {
+			return strings.Compare(a.(Resource)["path"].(string), b.(Resource)["path"].(string))
+		})
+		kustomization["patches"] = patches
+	}
+	overlay["kustomization.yaml"] = []any{kustomization}
+
+	for name, docs := range overlay {
+		var buf bytes.Buffer
+		e := yaml.NewEncoder(&buf)
+		e.SetIndent(p.indentSize)
+		for _, d := range docs {
+			if err := e.Encode(d); err != nil {
+				return err
+			}
+		}
+		if err := e.Close(); err != nil {
+			return err
+		}
+
+		if err := p.writeFile(path.Join(outputPath, overlayDir, name), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readOverlayInput reads resources, leaving out kustomizations
+func (p *Parser) readOverlayInput(files []string) ([]Resource, error) {
+	read, err := p.read(files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]Resource, 0, len(read))
+	for _, r := range read {
+		if kind, _ := r.Kind(); !strings.EqualFold(kind, "kustomization") {
+			resources = append(resources, r)
+		}
+	}
+	return resources, nil
+}
+
+// overlayKey identifies a resource across two renders by its api group, kind, namespace and name
+func overlayKey(r Resource) string {
+	return apiGroup(r) + "/" + r.Ref()
+}
+
+func apiGroup(r Resource) string {
+	apiVersion, _ := r["apiVersion"].(string)
+	group, _, ok := strings.Cut(apiVersion, "/")
+	if !ok {
+		return ""
+	}
+	return group
+}
+
+// builtIn reports whether a resource is served by kubernetes itself, so strategic merge patches use its merge keys
+func builtIn(r Resource) bool {
+	group := apiGroup(r)
+	return !strings.Contains(group, ".") || strings.HasSuffix(group, ".k8s.io")
+}
+
+func patchFileName(r Resource) string {
+	kind, _ := r.Kind()
+	name := strings.ToLower(kind) + "-" + r.Name()
+	if ns := r.Namespace(); ns != "" {
+		name = ns + "-" + name
+	}
+	return name + ".yaml"
+}
+
+// resourcePatch returns the patch turning from into to and its kustomization entry, without the path. Strategic
+// merge patches are objects while json6902 patches are lists of operations.
+func resourcePatch(from, to Resource, format string) (any, Resource) {
+	if format == PatchFormatStrategic {
+		if patch, ok := strategicPatch(plainValue(from).(map[string]any), plainValue(to).(map[string]any), builtIn(to)); ok {
+			patch["apiVersion"] = to["apiVersion"]
+			patch["kind"] = to["kind"]
+			metadata, _ := patch["metadata"].(map[string]any)
+			if metadata == nil {
+				metadata = make(map[string]any)
+			}
+			metadata["name"] = to.Name()
+			if ns := to.Namespace(); ns != "" {
+				metadata["namespace"] = ns
+			}
+			patch["metadata"] = metadata
+			return Resource(patch), Resource{}
+		}
+	}
+
+	ops := make([]any, 0)
+	jsonPatch(plainValue(from), plainValue(to), "", &ops)
+
+	kind, _ := to.Kind()
+	target := Resource{"kind": kind, "name": to.Name()}
+	if group := apiGroup(to); group != "" {
+		target["group"] = group
+	}
+	if ns := to.Namespace(); ns != "" {
+		target["namespace"] = ns
+	}
+
+	return ops, Resource{"target": target}
+}
+
+// deletePatch returns a strategic merge patch removing the resource
+func deletePatch(r Resource) Resource {
+	metadata := Resource{"name": r.Name()}
+	if ns := r.Namespace(); ns != "" {
+		metadata["namespace"] = ns
+	}
+	return Resource{
+		"apiVersion": r["apiVersion"],
+		"kind":       r["kind"],
+		"metadata":   metadata,
+		"$patch":     "delete",
+	}
+}
+
+// strategicPatch returns the strategic merge patch turning from into to. It reports false when the difference
+// cannot be expressed exactly, such as values removed from finalizers.
+func strategicPatch(from, to map[string]any, builtIn bool) (map[string]any, bool) {
+	patch := make(map[string]any)
+	for _, k := range sortedKeys(from) {
+		if _, ok := to[k]; !ok {
+			patch[k] = nil
+		}
+	}
+
+	for _, k := range sortedKeys(to) {
+		v, ok := from[k]
+		if ok && reflect.DeepEqual(v, to[k]) {
+			continue
+		}
+		if !ok {
+			patch[k] = to[k]
+			continue
+		}
+
+		fromMap, fromIsMap := v.(map[string]any)
+		toMap, toIsMap := to[k].(map[string]any)
+		if fromIsMap && toIsMap {
+			p, exact := strategicPatch(fromMap, toMap, builtIn)
+			if !exact {
+				return nil, false
+			}
+			patch[k] = p
+			continue
+		}
+
+		fromList, fromIsList := v.([]any)
+		toList, toIsList := to[k].([]any)
+		if builtIn && unpatchableLists[k] {
+			return nil, false
+		}
+		if builtIn && primitiveMergeLists[k] && fromIsList && toIsList {
+			p, exact := primitiveListPatch(fromList, toList)
+			if !exact {
+				return nil, false
+			}
+			patch[k] = p
+			continue
+		}
+		if key := listMergeKey(k, fromList, toList); builtIn && fromIsList && toIsList && key != "" {
+			p, exact := strategicListPatch(fromList, toList, key, builtIn)
+			if !exact {
+				return nil, false
+			}
+			patch[k] = p
+			continue
+		}
+
+		patch[k] = to[k]
+	}
+
+	return patch, true
+}
+
+// listMergeKey returns the key list items of a field are merged by, or an empty string when the list is replaced
+func listMergeKey(field string, lists ...[]any) string {
+	key := mergeKeys[field]
+	if field == "ports" {
+		key = "port"
+		for _, l := range lists {
+			for _, item := range l {
+				if m, ok := item.(map[string]any); ok && m["containerPort"] != nil {
+					key = "containerPort"
+				}
+			}
+		}
+	}
+	return key
+}
+
+// strategicListPatch patches a list merged by key. Kustomize places the items of the patch first, followed by the
+// remaining items in their original order, so the patch holds the shortest prefix of the new order after which every
+// item is unchanged and in place. Unchanged items in the prefix only hold their key. Items without a unique key
+// cannot be expressed.
+func strategicListPatch(from, to []any, key string, builtIn bool) ([]any, bool) {
+	fromItems, fromOrder, ok := keyedItems(from, key)
+	if !ok {
+		return nil, false
+	}
+	toItems, toOrder, ok := keyedItems(to, key)
+	if !ok {
+		return nil, false
+	}
+
+	unchanged := func(k string) bool {
+		item, ok := fromItems[k]
+		return ok && reflect.DeepEqual(item, toItems[k])
+	}
+
+	patch := make([]any, 0)
+	for _, k := range toOrder[:mergedPrefix(fromOrder, toOrder, unchanged)] {
+		item, ok := fromItems[k]
+		switch {
+		case !ok:
+			patch = append(patch, toItems[k])
+		case unchanged(k):
+			patch = append(patch, map[string]any{key: toItems[k][key]})
+		default:
+			p, exact := strategicPatch(item, toItems[k], builtIn)
+			if !exact {
+				return nil, false
+			}
+			p[key] = toItems[k][key]
+			patch = append(patch, p)
+		}
+	}
+
+	for _, k := range fromOrder {
+		if _, ok := toItems[k]; !ok {
+			patch = append(patch, map[string]any{key: fromItems[k][key], "$patch": "delete"})
+		}
+	}
+
+	return patch, true
+}
+
+// primitiveListPatch patches a list of values merged by kustomize, which places the values of the patch first. Values
+// can only be added, and every value must be unique.
+func primitiveListPatch(from, to []any) ([]any, bool) {
+	fromValues := make(map[string]bool, len(from))
+	fromOrder := make([]string, 0, len(from))
+	for _, v := range from {
+		fromValues[fmt.Sprint(v)] = true
+		fromOrder = append(fromOrder, fmt.Sprint(v))
+	}
+
+	toValues := make(map[string]bool, len(to))
+	toOrder := make([]string, 0, len(to))
+	for _, v := range to {
+		s := fmt.Sprint(v)
+		if toValues[s] {
+			return nil, false
+		}
+		toValues[s] = true
+		toOrder = append(toOrder, s)
+	}
+	if len(fromValues) != len(from) {
+		return nil, false
+	}
+	for _, s := range fromOrder {
+		if !toValues[s] {
+			return nil, false
+		}
+	}
+
+	n := mergedPrefix(fromOrder, toOrder, func(s string) bool { return fromValues[s] })
+	return slices.Clone(to[:n]), true
+}
+
+// mergedPrefix returns the length of the shortest prefix of to that, followed by the remaining items of from in their
+// order, yields to. Only items unchanged from from can follow the prefix.
+func mergedPrefix(from, to []string, unchanged func(string) bool) int {
+	for n := range to {
+		rest := to[n:]
+		if !slices.ContainsFunc(rest, func(k string) bool { return !unchanged(k) }) &&
+			slices.Equal(slices.DeleteFunc(slices.Clone(from), func(k string) bool { return !slices.Contains(rest, k) }), rest) {
+			return n
+		}
+	}
+	return len(to)
+}
+
+func keyedItems(list []any, key string) (map[string]map[string]any, []string, bool) {
+	items := make(map[string]map[string]any, len(list))
+	order := make([]string, 0, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok || m[key] == nil {
+			return nil, nil, false
+		}
+
+		k := fmt.Sprint(m[key])
+		if _, dup := items[k]; dup {
+			return nil, nil, false
+		}
+		items[k] = m
+		order = append(order, k)
+	}
+	return items, order, true
+}
+
+// jsonPatch appends the RFC 6902 operations turning from into to
+func jsonPatch(from, to any, pointer string, ops *[]any) {
+	if reflect.DeepEqual(from, to) {
+		return
+	}
+
+	fromMap, fromIsMap := from.(map[string]any)
+	toMap, toIsMap := to.(map[string]any)
+	if fromIsMap && toIsMap {
+		for _, k := range sortedKeys(fromMap) {
+			if _, ok := toMap[k]; !ok {
+				*ops = append(*ops, Resource{"op": "remove", "path": pointer + "/" + escapePointer(k)})
+			}
+		}
+		for _, k := range sortedKeys(toMap) {
+			v, ok := fromMap[k]
+			if !ok {
+				*ops = append(*ops, Resource{"op": "add", "path": pointer + "/" + escapePointer(k), "value": toMap[k]})
+				continue
+			}
+			jsonPatch(v, toMap[k], pointer+"/"+escapePointer(k), ops)
+		}
+		return
+	}
+
+	fromList, fromIsList := from.([]any)
+	toList, toIsList := to.([]any)
+	if fromIsList && toIsList && len(fromList) == len(toList) {
+		for i := range toList {
+			jsonPatch(fromList[i], toList[i], fmt.Sprintf("%s/%d", pointer, i), ops)
+		}
+		return
+	}
+
+	*ops = append(*ops, Resource{"op": "replace", "path": pointer, "value": to})
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// plainValue converts decoded yaml into plain maps and lists so resources can be compared and walked
+func plainValue(v any) any {
+	switch v := v.(type) {
+	case Resource:
+		return plainValue(map[string]any(v))
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = plainValue(item)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, item := range v {
+			l[i] = plainValue(item)
+		}
+		return l
+	default:
+		return v
+	}
+}
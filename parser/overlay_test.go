@@ -0,0 +1,270 @@
+package parser
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func deployment(env ...string) Resource {
+	vars := make([]any, 0, len(env))
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		vars = append(vars, map[string]any{"name": name, "value": value})
+	}
+	return Resource{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "web"},
+		"spec": map[string]any{"template": map[string]any{"spec": map[string]any{"containers": []any{
+			map[string]any{"name": "web", "image": "web:1.0", "env": vars},
+		}}}},
+	}
+}
+
+func TestResourcePatch(t *testing.T) {
+	containerPatch := func(env ...any) Resource {
+		return Resource{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "web"},
+			"spec": map[string]any{"template": map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "web", "env": env},
+			}}}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		from      Resource
+		to        Resource
+		format    string
+		wantPatch any
+		wantEntry Resource
+	}{
+		{
+			name:      "strategic changes and deletes keyed items",
+			from:      deployment("DEBUG=true", "LOG=info"),
+			to:        deployment("LOG=warn", "TRACE=1"),
+			format:    PatchFormatStrategic,
+			wantPatch: containerPatch(map[string]any{"name": "LOG", "value": "warn"}, map[string]any{"name": "TRACE", "value": "1"}, map[string]any{"name": "DEBUG", "$patch": "delete"}),
+			wantEntry: Resource{},
+		},
+		{
+			name:      "strategic moves reordered items to the front",
+			from:      deployment("A=1", "B=2"),
+			to:        deployment("B=2", "A=1"),
+			format:    PatchFormatStrategic,
+			wantPatch: containerPatch(map[string]any{"name": "B"}),
+			wantEntry: Resource{},
+		},
+		{
+			name:      "strategic lists items before an added one",
+			from:      deployment("A=1", "B=2"),
+			to:        deployment("A=1", "C=3", "B=2"),
+			format:    PatchFormatStrategic,
+			wantPatch: containerPatch(map[string]any{"name": "A"}, map[string]any{"name": "C", "value": "3"}),
+			wantEntry: Resource{},
+		},
+		{
+			name:   "strategic falls back to json6902 for removed finalizers",
+			from:   Resource{"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]any{"name": "web", "finalizers": []any{"a", "b"}}},
+			to:     Resource{"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]any{"name": "web", "finalizers": []any{"a"}}},
+			format: PatchFormatStrategic,
+			wantPatch: []any{
+				Resource{"op": "replace", "path": "/metadata/finalizers", "value": []any{"a"}},
+			},
+			wantEntry: Resource{"target": Resource{"kind": "Namespace", "name": "web"}},
+		},
+		{
+			name:   "json6902 replaces lists that changed length",
+			from:   deployment("A=1"),
+			to:     deployment(),
+			format: PatchFormatJSON6902,
+			wantPatch: []any{
+				Resource{"op": "replace", "path": "/spec/template/spec/containers/0/env", "value": []any{}},
+			},
+			wantEntry: Resource{"target": Resource{"group": "apps", "kind": "Deployment", "name": "web"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, entry := resourcePatch(tt.from, tt.to, tt.format)
+			if !reflect.DeepEqual(patch, tt.wantPatch) {
+				t.Errorf("resourcePatch() patch = %v, want %v", patch, tt.wantPatch)
+			}
+			if !reflect.DeepEqual(entry, tt.wantEntry) {
+				t.Errorf("resourcePatch() entry = %v, want %v", entry, tt.wantEntry)
+			}
+		})
+	}
+}
+
+func TestStrategicPatch_customResources(t *testing.T) {
+	from := map[string]any{"spec": map[string]any{"containers": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}, "paused": true}}
+	to := map[string]any{"spec": map[string]any{"containers": []any{map[string]any{"name": "a"}}}}
+
+	want := map[string]any{"spec": map[string]any{"containers": []any{map[string]any{"name": "a"}}, "paused": nil}}
+	got, ok := strategicPatch(from, to, false)
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("strategicPatch() = %v, %v, want %v", got, ok, want)
+	}
+}
+
+func TestEscapePointer(t *testing.T) {
+	if got := escapePointer("app.kubernetes.io/name~x"); got != "app.kubernetes.io~1name~0x" {
+		t.Errorf("escapePointer() = %q", got)
+	}
+}
+
+func TestParser_Overlay_kustomizeBuild(t *testing.T) {
+	if _, err := exec.LookPath("kustomize"); err != nil {
+		t.Skip("kustomize is not installed")
+	}
+
+	base := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  finalizers: [a, b]
+spec:
+  template:
+    spec:
+      hostAliases:
+        - ip: 1.1.1.1
+          hostnames: [x]
+        - ip: 2.2.2.2
+          hostnames: [y]
+      topologySpreadConstraints:
+        - topologyKey: zone
+          maxSkew: 1
+          whenUnsatisfiable: DoNotSchedule
+        - topologyKey: host
+          maxSkew: 1
+          whenUnsatisfiable: DoNotSchedule
+      containers:
+        - name: a
+          image: a
+          env:
+            - name: A
+              value: "1"
+            - name: B
+              value: "2"
+        - name: b
+          image: b
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  finalizers: [a]
+  ownerReferences:
+    - {apiVersion: v1, kind: X, name: a, uid: u1}
+    - {apiVersion: v1, kind: X, name: b, uid: u2}
+spec:
+  containers:
+    - name: web
+      image: web
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+spec:
+  items:
+    - name: a
+    - name: b
+`
+	variant := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  finalizers: [a]
+spec:
+  template:
+    spec:
+      hostAliases:
+        - ip: 1.1.1.1
+          hostnames: [x]
+      topologySpreadConstraints:
+        - topologyKey: zone
+          maxSkew: 1
+          whenUnsatisfiable: DoNotSchedule
+      containers:
+        - name: c
+          image: c
+        - name: a
+          image: a
+          env:
+            - name: B
+              value: "2"
+            - name: C
+              value: "3"
+            - name: A
+              value: "1"
+        - name: b
+          image: b2
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  finalizers: [a, c]
+  ownerReferences:
+    - {apiVersion: v1, kind: X, name: b, uid: u2}
+spec:
+  hostAliases:
+    - ip: 3.3.3.3
+      hostnames: [z]
+  containers:
+    - name: web
+      image: web
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+spec:
+  items:
+    - name: b
+`
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"base.yaml": base, "variant.yaml": variant} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(dir, "out")
+	p := New()
+	if err := p.Overlay([]string{filepath.Join(dir, "base.yaml")}, []string{filepath.Join(dir, "variant.yaml")}, out, OverlayOptions{}); err != nil {
+		t.Fatalf("Overlay() error = %v", err)
+	}
+
+	built, err := exec.Command("kustomize", "build", filepath.Join(out, overlaysDir, defaultOverlayName)).Output()
+	if err != nil {
+		t.Fatalf("kustomize build: %v", err)
+	}
+
+	byKey := func(input string) map[string]any {
+		m := make(map[string]any)
+		for _, r := range readResource(strings.NewReader(input)) {
+			m[overlayKey(r)] = plainValue(r)
+		}
+		return m
+	}
+	got, want := byKey(string(built)), byKey(variant)
+	for key, w := range want {
+		if !reflect.DeepEqual(got[key], w) {
+			t.Errorf("kustomize build %s = %v, want %v", key, got[key], w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("kustomize build returned %d resources, want %d", len(got), len(want))
+	}
+}
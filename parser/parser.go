@@ -3,7 +3,6 @@ package parser
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"io"
 	"os"
 	"path"
@@ -13,11 +12,27 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/kdwils/splinter/pkg/fio"
+	"github.com/kdwils/splinter/pkg/schema"
+	"github.com/kdwils/splinter/pkg/sops"
 )
 
 type Parser struct {
-	indentSize int
-	fio        fio.FileIO
+	indentSize            int
+	fio                   fio.FileIO
+	transforms            []Transform
+	extractData           bool
+	separateCRDs          bool
+	separateClusterScoped bool
+	schemas               *schema.Set
+	checkReferences       bool
+	secretsDir            string
+	failOnSecrets         bool
+	decrypter             *sops.Decrypter
+	argoCD                *ArgoCDOptions
+	flux                  *FluxOptions
+	kustomization         KustomizationOptions
+	updateKustomization   bool
+	lock                  *lockRecorder
 }
 
 const (
@@ -51,26 +66,21 @@ func WithFileIO(fio fio.FileIO) ParserOpt {
 	}
 }
 
-func (p *Parser) Merge(files []string, stdin io.Reader, outputPath string) error {
-	resources := make([]Resource, 0)
-
-	if stdin != nil {
-		resources = append(resources, readResource(stdin)...)
+// WithTransforms appends transforms that are applied, in order, to every resource set read by the parser
+func WithTransforms(transforms ...Transform) ParserOpt {
+	return func(p *Parser) {
+		p.transforms = append(p.transforms, transforms...)
 	}
+}
 
-	for _, f := range p.filesFromInput(files) {
-		buf, err := p.readFileToBuffer(f)
-		if err != nil {
-			return err
-		}
-		for _, r := range readResource(buf) {
-			_, err := r.Kind()
-			if err != nil {
-				continue
-			}
+func (p *Parser) Merge(files []string, stdin io.Reader, outputPath string) error {
+	resources, err := p.read(files, stdin)
+	if err != nil {
+		return err
+	}
 
-			resources = append(resources, r)
-		}
+	if err := p.checkPlainSecrets(resources); err != nil {
+		return err
 	}
 
 	if outputPath != "" {
@@ -81,42 +91,90 @@ func (p *Parser) Merge(files []string, stdin io.Reader, outputPath string) error
 }
 
 func (p *Parser) Split(inputFiles []string, stdin io.Reader, outputPath string, kustomize bool) error {
-	resources := make([]Resource, 0)
+	read, err := p.read(inputFiles, stdin)
+	if err != nil {
+		return err
+	}
 
-	if stdin != nil {
-		resources = append(resources, readResource(stdin)...)
+	return p.splitResources(read, outputPath, kustomize)
+}
+
+// splitResources writes resources read by Split into outputPath
+func (p *Parser) splitResources(read []Resource, outputPath string, kustomize bool) error {
+	// flux builds a kustomization for every path without one, which would pull in the other groups
+	kustomize = kustomize || p.flux != nil
+
+	var err error
+	resources := make([]Resource, 0, len(read))
+	for _, r := range read {
+		kind, _ := r.Kind()
+		if strings.EqualFold(kind, "kustomization") {
+			continue
+		}
+		resources = append(resources, r)
 	}
 
-	for _, f := range p.filesFromInput(inputFiles) {
-		buf, err := p.readFileToBuffer(f)
+	if err := p.checkPlainSecrets(resources); err != nil {
+		return err
+	}
+
+	resources, secrets := p.routeSecrets(resources)
+
+	var generators map[string][]generator
+	if p.extractData {
+		if !kustomize {
+			return ErrExtractRequiresKustomize
+		}
+
+		resources, generators, err = p.extract(outputPath, resources)
 		if err != nil {
 			return err
 		}
-		for _, r := range readResource(buf) {
-			kind, err := r.Kind()
-			if err != nil {
-				continue
-			}
-			if strings.EqualFold(kind, "kustomization") {
-				continue
-			}
+	}
 
-			resources = append(resources, r)
+	files := p.groupFiles(resources)
+	groups := outputGroups(files)
+	if _, ok := groups[""]; !ok && len(generators) > 0 {
+		groups[""] = []string{}
+	}
+
+	// the gitops resources read the namespaces of the groups, so they are generated before kustomizations hoist them
+	if p.argoCD != nil {
+		apps, err := p.argoCDApplications(outputPath, groups, files)
+		if err != nil {
+			return err
 		}
+		files[path.Join(argoCDDir, "applications.yaml")] = apps
 	}
 
-	resourceMap := resourcesToMap(resources)
+	if p.flux != nil {
+		kustomizations, err := p.fluxKustomizations(outputPath, groups)
+		if err != nil {
+			return err
+		}
+		files[path.Join(fluxDir, "kustomizations.yaml")] = kustomizations
+	}
 
 	if kustomize {
-		resources := make([]string, 0)
-		for k := range resourceMap {
-			resources = append(resources, fmt.Sprintf("%s.yaml", strings.ToLower(k)))
+		if err := p.addKustomizations(files, groups, generators); err != nil {
+			return err
 		}
-		resourceMap["kustomization"] = append(resourceMap["kustomization"], newKustomizeResource(resources...))
 	}
 
-	for k, v := range resourceMap {
-		filepath := path.Join(outputPath, fmt.Sprintf("%s.yaml", strings.ToLower(k)))
+	if kustomize && p.updateKustomization {
+		if err := p.updateKustomizations(outputPath, files); err != nil {
+			return err
+		}
+	}
+
+	if len(secrets) > 0 {
+		if err := p.write(path.Join(p.secretsDir, "secret.yaml"), p.indentSize, secrets...); err != nil {
+			return err
+		}
+	}
+
+	for name, v := range files {
+		filepath := path.Join(outputPath, name)
 		err := p.write(filepath, p.indentSize, v...)
 		if err != nil {
 			return err
@@ -126,6 +184,208 @@ func (p *Parser) Split(inputFiles []string, stdin io.Reader, outputPath string,
 	return nil
 }
 
+// addKustomizations adds a kustomization listing the split output. When the groups are deployed separately,
+// every group directory gets its own kustomization instead of a single one in the output directory.
+func (p *Parser) addKustomizations(files map[string][]Resource, groups map[string][]string, generators map[string][]generator) error {
+	fileName, err := p.kustomizationFile()
+	if err != nil {
+		return err
+	}
+
+	all := make([]Resource, 0, len(files))
+	for _, rs := range files {
+		all = append(all, rs...)
+	}
+	clusterKinds := clusterScopedCustomKinds(all)
+
+	if !p.kustomizationPerGroup() {
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		groups = map[string][]string{"": names}
+	}
+
+	for group, names := range groups {
+		paths := make([]string, 0, len(names))
+		resources := make([]Resource, 0, len(names))
+		for _, name := range names {
+			rel, _ := filepath.Rel(group, name)
+			paths = append(paths, filepath.ToSlash(rel))
+			resources = append(resources, files[name]...)
+		}
+
+		k := newKustomizeResource(paths...)
+		p.applyKustomizationOptions(k, resources, clusterKinds)
+		if group == "" {
+			for key, g := range generators {
+				k[key] = g
+			}
+		}
+
+		name := path.Join(group, fileName)
+		files[name] = append(files[name], k)
+	}
+
+	return nil
+}
+
+// kustomizationPerGroup reports whether the groups of the split output are deployed separately
+func (p *Parser) kustomizationPerGroup() bool {
+	return p.argoCD != nil || p.flux != nil
+}
+
+// Images reads the input and returns every container image referenced by a workload along with the resources using it
+func (p *Parser) Images(inputFiles []string, stdin io.Reader) ([]ImageUsage, error) {
+	resources, err := p.read(inputFiles, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	return imageInventory(resources), nil
+}
+
+// read collects every resource with a kind from stdin and the input files, then applies the parser's transforms
+func (p *Parser) read(inputFiles []string, stdin io.Reader) ([]Resource, error) {
+	docs, err := p.readDocuments(inputFiles, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.resources(docs)
+}
+
+// resources validates the documents, expands generators, checks references and applies the parser's transforms
+func (p *Parser) resources(docs []document) ([]Resource, error) {
+	if p.schemas != nil {
+		if errs := p.validate(docs); len(errs) > 0 {
+			return nil, errs
+		}
+	}
+
+	resources := make([]Resource, 0, len(docs))
+	for _, d := range docs {
+		kind, err := d.resource.Kind()
+		if err != nil {
+			continue
+		}
+
+		if p.extractData && strings.EqualFold(kind, "kustomization") {
+			dp, dir := p, path.Dir(d.source)
+			if d.files != nil {
+				// generator files of an archived or committed kustomization are read from where it came from
+				fp := *p
+				fp.fio = d.files
+				dp, dir = &fp, path.Dir(d.path)
+			}
+
+			generated, err := dp.expandGenerators(dir, d.resource)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, generated...)
+		}
+		resources = append(resources, d.resource)
+	}
+
+	if p.checkReferences {
+		if findings := lint(docs, resources, []Rule{DanglingReferenceRule()}); len(findings) > 0 {
+			return nil, DanglingReferencesError(findings)
+		}
+	}
+
+	return p.transform(resources)
+}
+
+// readDocuments decodes every yaml document from stdin and the input files, decrypting them when configured
+func (p *Parser) readDocuments(inputFiles []string, stdin io.Reader) ([]document, error) {
+	docs := make([]document, 0)
+
+	if stdin != nil {
+		in := []Stream{{Name: stdinSource, Reader: stdin}}
+		if p.lock != nil {
+			if err := p.lock.record(stdinSource, in); err != nil {
+				return nil, err
+			}
+		}
+		docs = append(docs, readDocuments(stdinSource, in[0].Reader)...)
+	}
+
+	streams, err := p.inputStreams(inputFiles)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range streams {
+		docs = append(docs, streamDocuments(s)...)
+	}
+
+	if err := p.decrypt(docs); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// inputStreams returns a stream for every file the inputs name: yaml files, the files of directories and archives,
+// and files at a git revision
+func (p *Parser) inputStreams(inputs []string) ([]Stream, error) {
+	streams := make([]Stream, 0, len(inputs))
+	for _, input := range inputs {
+		s, err := p.streams(input)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.lock != nil {
+			if err := p.lock.record(input, s); err != nil {
+				return nil, err
+			}
+		}
+		streams = append(streams, s...)
+	}
+
+	return streams, nil
+}
+
+// streams returns the streams of a single input
+func (p *Parser) streams(input string) ([]Stream, error) {
+	if isGitInput(input) {
+		return p.gitStreams(input)
+	}
+
+	streams := make([]Stream, 0)
+	for _, f := range p.filesFromInput([]string{input}) {
+		if ArchiveFormat(f) != "" {
+			s, err := p.archiveStreams(f)
+			if err != nil {
+				return nil, err
+			}
+			streams = append(streams, s...)
+			continue
+		}
+
+		buf, err := p.readFileToBuffer(f)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, Stream{Name: f, Reader: buf})
+	}
+
+	return streams, nil
+}
+
+func (p *Parser) transform(resources []Resource) ([]Resource, error) {
+	var err error
+	for _, t := range p.transforms {
+		resources, err = t(resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resources, nil
+}
+
 func (p *Parser) readFileToBuffer(file string) (*bytes.Buffer, error) {
 	b, err := p.fio.ReadFile(file)
 	if err != nil {
@@ -137,7 +397,7 @@ func (p *Parser) readFileToBuffer(file string) (*bytes.Buffer, error) {
 func (p *Parser) filesFromInput(input []string) []string {
 	files := make([]string, 0)
 	for _, f := range input {
-		if strings.EqualFold(filepath.Ext(f), ".yaml") {
+		if strings.EqualFold(filepath.Ext(f), ".yaml") || ArchiveFormat(f) != "" || fio.IsURL(f) {
 			files = append(files, f)
 			continue
 		}
@@ -151,13 +411,40 @@ func (p *Parser) filesFromInput(input []string) []string {
 			continue
 		}
 
-		dir, err := p.fio.ReadDir(f)
-		if err != nil {
+		files = append(files, p.filesFromDir(f)...)
+	}
+
+	return files
+}
+
+// filesFromDir lists the files of a directory along with the yaml files of the crds and cluster directories
+// written by split. Other subdirectories, such as overlays or extracted data, are not read.
+func (p *Parser) filesFromDir(dir string) []string {
+	entries, err := p.fio.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	files := make([]string, 0)
+	for _, e := range entries {
+		name := path.Join(dir, e.Name())
+		if !e.IsDir() {
+			files = append(files, name)
+			continue
+		}
+		if e.Name() != crdsDir && e.Name() != clusterDir {
 			continue
 		}
 
-		for _, file := range dir {
-			files = append(files, path.Join(f, file.Name()))
+		nested, err := p.fio.ReadDir(name)
+		if err != nil {
+			continue
+		}
+		for _, n := range nested {
+			ext := strings.ToLower(filepath.Ext(n.Name()))
+			if !n.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, path.Join(name, n.Name()))
+			}
 		}
 	}
 
@@ -2,9 +2,14 @@ package parser
 
 import (
 	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/kdwils/splinter/pkg/fio"
 	"github.com/kdwils/splinter/pkg/fio/mocks"
 	"go.uber.org/mock/gomock"
 )
@@ -134,6 +139,90 @@ func TestParser_Split(t *testing.T) {
 			t.Errorf("expected no error, got %v", err)
 		}
 	})
+
+	t.Run("split and merge in memory", func(t *testing.T) {
+		input, err := os.ReadFile("./testing/input.yaml")
+		if err != nil {
+			t.Fatalf("failed to read test file: %v", err)
+		}
+		crd := []byte("apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: foos.example.com\n")
+
+		m := fio.NewMemory()
+		m.AddFile("input.yaml", append(append(input, "---\n"...), crd...))
+
+		p := New(WithFileIO(m), WithSeparateCRDs(true))
+		if err := p.Split([]string{"input.yaml"}, nil, "output", true); err != nil {
+			t.Fatalf("Split() error = %v", err)
+		}
+
+		want := []string{"crds/customresourcedefinition.yaml", "deployment.yaml", "kustomization.yaml", "service.yaml"}
+		if got := m.FilesIn("output"); !slices.Equal(got, want) {
+			t.Fatalf("split files = %v, want %v", got, want)
+		}
+		if got, _ := m.Contents("output/crds/customresourcedefinition.yaml"); got != string(crd) {
+			t.Errorf("crd =\n%s\nwant\n%s", got, crd)
+		}
+
+		if err := p.Merge([]string{"output"}, nil, "merged.yaml"); err != nil {
+			t.Fatalf("Merge() error = %v", err)
+		}
+		merged, _ := m.Contents("merged.yaml")
+		if got := len(readResource(strings.NewReader(merged))); got != 4 {
+			t.Errorf("merged %d documents, want 4", got)
+		}
+	})
+}
+
+func TestParser_Merge_remoteInput(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: operator\n"))
+	}))
+	defer srv.Close()
+
+	local := fio.NewMemory()
+	local.AddFile("in/deployment.yaml", []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n"))
+	p := New(WithFileIO(fio.NewHTTP(local, fio.WithCacheDir(t.TempDir()))))
+
+	if err := p.Merge([]string{srv.URL + "/releases/latest/install", "in"}, nil, "merged.yaml"); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	b, err := local.ReadFile("merged.yaml")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{"kind: Namespace", "kind: Deployment"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("merged output is missing %q:\n%s", want, b)
+		}
+	}
+}
+
+func TestParser_filesFromInput(t *testing.T) {
+	m := fio.NewMemory()
+	for _, name := range []string{
+		"base/service.yaml",
+		"base/kustomization.yaml",
+		"base/crds/customresourcedefinition.yaml",
+		"base/crds/README.md",
+		"base/cluster/namespace.yaml",
+		"base/overlays/prod/patch.yaml",
+		"base/configmaps/web/settings/app.yaml",
+		"single.yaml",
+	} {
+		m.AddFile(name, nil)
+	}
+
+	want := []string{
+		"base/cluster/namespace.yaml",
+		"base/crds/customresourcedefinition.yaml",
+		"base/kustomization.yaml",
+		"base/service.yaml",
+		"single.yaml",
+	}
+	if got := New(WithFileIO(m)).filesFromInput([]string{"base", "single.yaml"}); !slices.Equal(got, want) {
+		t.Errorf("filesFromInput() = %v, want %v", got, want)
+	}
 }
 
 func TestWrite(t *testing.T) {
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	PluginFormatStream       = "stream"
+	PluginFormatResourceList = "resourceList"
+)
+
+var (
+	ErrMissingPluginCommand = errors.New("plugin requires a command")
+	ErrInvalidPluginFormat  = errors.New("plugin format must be stream or resourceList")
+	ErrPluginFailed         = errors.New("plugin failed")
+)
+
+// Plugin is an executable transforming resources. It receives the resources on stdin and writes the transformed set
+// to stdout, either as a yaml stream or as the items of a KRM ResourceList.
+type Plugin struct {
+	Name    string
+	Command string
+	Args    []string
+	// Env is added to the environment splinter runs in
+	Env map[string]string
+	// Format is stream or resourceList, matched case-insensitively, defaulting to stream
+	Format string
+	// FunctionConfig is passed as the functionConfig of the ResourceList
+	FunctionConfig map[string]any
+}
+
+// PluginTransform returns a transform running the plugin. Resources written by a stream plugin replace the set as is;
+// a resourceList plugin fails when it reports a result with severity error.
+func PluginTransform(plugin Plugin) (Transform, error) {
+	if plugin.Command == "" {
+		return nil, fmt.Errorf("%w: %q", ErrMissingPluginCommand, plugin.Name)
+	}
+	switch strings.ToLower(plugin.Format) {
+	case "", strings.ToLower(PluginFormatStream):
+		plugin.Format = PluginFormatStream
+	case strings.ToLower(PluginFormatResourceList):
+		plugin.Format = PluginFormatResourceList
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPluginFormat, plugin.Format)
+	}
+
+	return func(resources []Resource) ([]Resource, error) {
+		var in bytes.Buffer
+		if plugin.Format == PluginFormatResourceList {
+			list := Resource{
+				"apiVersion": resourceListAPIVersion,
+				"kind":       resourceListKind,
+				"items":      resources,
+			}
+			if plugin.FunctionConfig != nil {
+				list["functionConfig"] = plugin.FunctionConfig
+			}
+			if err := write(&in, defaultIndentSize, list); err != nil {
+				return nil, err
+			}
+		} else if err := write(&in, defaultIndentSize, resources...); err != nil {
+			return nil, err
+		}
+
+		out, err := plugin.run(&in)
+		if err != nil {
+			return nil, err
+		}
+
+		if plugin.Format == PluginFormatResourceList {
+			return plugin.resourceListItems(out)
+		}
+
+		transformed := make([]Resource, 0, len(resources))
+		for _, r := range readResource(bytes.NewReader(out)) {
+			if len(r) > 0 {
+				transformed = append(transformed, r)
+			}
+		}
+		return transformed, nil
+	}, nil
+}
+
+// run executes the plugin with in as stdin and returns its stdout
+func (plugin Plugin) run(in *bytes.Buffer) ([]byte, error) {
+	cmd := exec.Command(plugin.Command, plugin.Args...)
+	cmd.Stdin = in
+	cmd.Env = os.Environ()
+	for k, v := range plugin.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s: %w: %s", ErrPluginFailed, plugin.name(), err, msg)
+		}
+		return nil, fmt.Errorf("%w: %s: %w", ErrPluginFailed, plugin.name(), err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// resourceListItems returns the items of the ResourceList written by the plugin, failing on error results
+func (plugin Plugin) resourceListItems(out []byte) ([]Resource, error) {
+	var list struct {
+		Kind    string           `yaml:"kind"`
+		Items   []Resource       `yaml:"items"`
+		Results []FunctionResult `yaml:"results"`
+	}
+	if err := yaml.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrPluginFailed, plugin.name(), err)
+	}
+	if list.Kind != resourceListKind {
+		return nil, fmt.Errorf("%w: %s: %w", ErrPluginFailed, plugin.name(), ErrNotResourceList)
+	}
+
+	errs := make([]string, 0)
+	for _, r := range list.Results {
+		if r.Severity == SeverityError {
+			errs = append(errs, r.Message)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %s: %s", ErrPluginFailed, plugin.name(), strings.Join(errs, "; "))
+	}
+
+	if list.Items == nil {
+		list.Items = make([]Resource, 0)
+	}
+	return list.Items, nil
+}
+
+func (plugin Plugin) name() string {
+	if plugin.Name != "" {
+		return plugin.Name
+	}
+	return plugin.Command
+}
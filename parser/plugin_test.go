@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPluginTransform(t *testing.T) {
+	resources := func() []Resource {
+		return []Resource{
+			{"apiVersion": "v1", "kind": "Service", "metadata": Resource{"name": "web"}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		plugin     Plugin
+		want       []Resource
+		wantErr    error
+		wantNewErr error
+	}{
+		{
+			name:   "stream",
+			plugin: Plugin{Command: "sh", Args: []string{"-c", "sed s/web/api/"}},
+			want:   []Resource{{"apiVersion": "v1", "kind": "Service", "metadata": Resource{"name": "api"}}},
+		},
+		{
+			name:   "stream adding a resource",
+			plugin: Plugin{Command: "sh", Args: []string{"-c", `cat; printf -- '---\nkind: ConfigMap\n'`}},
+			want: []Resource{
+				{"apiVersion": "v1", "kind": "Service", "metadata": Resource{"name": "web"}},
+				{"kind": "ConfigMap"},
+			},
+		},
+		{
+			name:   "env",
+			plugin: Plugin{Command: "sh", Args: []string{"-c", `sed "s/web/$NAME/"`}, Env: map[string]string{"NAME": "env"}},
+			want:   []Resource{{"apiVersion": "v1", "kind": "Service", "metadata": Resource{"name": "env"}}},
+		},
+		{
+			name: "resource list",
+			plugin: Plugin{
+				Command:        "sh",
+				Args:           []string{"-c", "sed s/web/api/"},
+				Format:         "resourcelist",
+				FunctionConfig: map[string]any{"kind": "ConfigMap"},
+			},
+			want: []Resource{{"apiVersion": "v1", "kind": "Service", "metadata": Resource{"name": "api"}}},
+		},
+		{
+			name: "resource list error result",
+			plugin: Plugin{
+				Command: "sh",
+				Args:    []string{"-c", `cat; printf 'results:\n  - message: nope\n    severity: error\n'`},
+				Format:  PluginFormatResourceList,
+			},
+			wantErr: ErrPluginFailed,
+		},
+		{
+			name:    "exit status",
+			plugin:  Plugin{Name: "fails", Command: "sh", Args: []string{"-c", "echo broken >&2; exit 3"}},
+			wantErr: ErrPluginFailed,
+		},
+		{
+			name:       "missing command",
+			plugin:     Plugin{Name: "empty"},
+			wantNewErr: ErrMissingPluginCommand,
+		},
+		{
+			name:       "invalid format",
+			plugin:     Plugin{Command: "cat", Format: "json"},
+			wantNewErr: ErrInvalidPluginFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transform, err := PluginTransform(tt.plugin)
+			if !errors.Is(err, tt.wantNewErr) {
+				t.Fatalf("PluginTransform() error = %v, want %v", err, tt.wantNewErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got, err := transform(resources())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("transform() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("transform() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"github.com/kdwils/splinter/pkg/policy"
+)
+
+// PolicyRule adapts a compiled CEL policy into a Rule reporting every failed validation of matching resources
+func PolicyRule(p *policy.Compiled) Rule {
+	severity := p.Severity
+	if severity == "" {
+		severity = SeverityError
+	}
+
+	description := p.Name
+	if len(p.Validations) == 1 && p.Validations[0].Message != "" {
+		description = p.Validations[0].Message
+	}
+
+	return NewRule(p.Name, description, severity, func(r Resource, _ []Resource) []string {
+		kind, _ := r.Kind()
+		if !p.Matches(kind, r.Namespace()) {
+			return nil
+		}
+		return p.Evaluate(r)
+	})
+}
@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reference is an object a resource refers to by name
+type reference struct {
+	kind      string
+	namespace string
+	name      string
+	field     string
+}
+
+// DanglingReferencesError lists references to objects missing from the manifest set
+type DanglingReferencesError []Finding
+
+func (e DanglingReferencesError) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, f := range e {
+		lines = append(lines, fmt.Sprintf("%s:%d %s: %s", f.Source, f.Line, f.Resource, f.Message))
+	}
+	return fmt.Sprintf("%d dangling references:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// WithReferenceCheck fails reading when a resource refers to a ConfigMap, Secret, ServiceAccount,
+// PersistentVolumeClaim, Service or Role that is not part of the input
+func WithReferenceCheck(check bool) ParserOpt {
+	return func(p *Parser) {
+		p.checkReferences = check
+	}
+}
+
+// DanglingReferenceRule reports references to objects that are missing from the manifest set
+func DanglingReferenceRule() Rule {
+	return NewRule("dangling-reference", "referenced ConfigMaps, Secrets, ServiceAccounts, PersistentVolumeClaims, Services and Roles should be in the manifest set", SeverityError, checkReferences)
+}
+
+func checkReferences(r Resource, resources []Resource) []string {
+	refs := r.references()
+	if len(refs) == 0 {
+		return nil
+	}
+
+	present := make(map[reference]bool, len(resources))
+	for _, res := range resources {
+		kind, _ := res.Kind()
+		present[reference{kind: kind, namespace: res.Namespace(), name: res.Name()}] = true
+	}
+
+	msgs := make([]string, 0)
+	for _, ref := range refs {
+		key := reference{kind: ref.kind, namespace: ref.namespace, name: ref.name}
+		if present[key] {
+			continue
+		}
+		msgs = append(msgs, fmt.Sprintf("%s references %s %q which is not in the manifest set", ref.field, ref.kind, ref.name))
+	}
+
+	return msgs
+}
+
+// references returns every object the resource refers to by name
+func (r Resource) references() []reference {
+	kind, _ := r.Kind()
+	ns := r.Namespace()
+	refs := make([]reference, 0)
+	add := func(kind, namespace, field string, name any) {
+		if s, ok := name.(string); ok && s != "" {
+			refs = append(refs, reference{kind: kind, namespace: namespace, name: s, field: field})
+		}
+	}
+
+	if spec, ok := r.podSpec(); ok {
+		refs = append(refs, podSpecReferences(spec, ns)...)
+	}
+
+	switch kind {
+	case "Ingress":
+		spec, _ := nestedMap(r, "spec")
+		backends := []map[string]any{}
+		for _, key := range []string{"defaultBackend", "backend"} {
+			if b, ok := nestedMap(spec, key); ok {
+				backends = append(backends, b)
+			}
+		}
+		for _, rule := range listOfMaps(spec["rules"]) {
+			paths, _ := nestedMap(rule, "http")
+			for _, p := range listOfMaps(paths["paths"]) {
+				if b, ok := nestedMap(p, "backend"); ok {
+					backends = append(backends, b)
+				}
+			}
+		}
+		for _, b := range backends {
+			if svc, ok := nestedMap(b, "service"); ok {
+				add("Service", ns, "ingress backend", svc["name"])
+			}
+			add("Service", ns, "ingress backend", b["serviceName"])
+		}
+		for _, tls := range listOfMaps(spec["tls"]) {
+			add("Secret", ns, "ingress tls", tls["secretName"])
+		}
+	case "RoleBinding", "ClusterRoleBinding":
+		roleRef, _ := nestedMap(r, "roleRef")
+		if roleRef["kind"] == "Role" {
+			add("Role", ns, "roleRef", roleRef["name"])
+		}
+	}
+
+	return refs
+}
+
+func podSpecReferences(spec map[string]any, ns string) []reference {
+	refs := make([]reference, 0)
+	add := func(kind, field string, source map[string]any, key string) {
+		if optional, _ := source["optional"].(bool); optional {
+			return
+		}
+		if s, ok := source[key].(string); ok && s != "" {
+			refs = append(refs, reference{kind: kind, namespace: ns, name: s, field: field})
+		}
+	}
+
+	if sa, ok := spec["serviceAccountName"].(string); ok && sa != "" && sa != "default" {
+		refs = append(refs, reference{kind: "ServiceAccount", namespace: ns, name: sa, field: "serviceAccountName"})
+	}
+
+	for _, s := range listOfMaps(spec["imagePullSecrets"]) {
+		add("Secret", "imagePullSecrets", s, "name")
+	}
+
+	for _, v := range listOfMaps(spec["volumes"]) {
+		field := fmt.Sprintf("volume %q", v["name"])
+		if cm, ok := nestedMap(v, "configMap"); ok {
+			add("ConfigMap", field, cm, "name")
+		}
+		if secret, ok := nestedMap(v, "secret"); ok {
+			add("Secret", field, secret, "secretName")
+		}
+		if pvc, ok := nestedMap(v, "persistentVolumeClaim"); ok {
+			add("PersistentVolumeClaim", field, pvc, "claimName")
+		}
+		projected, _ := nestedMap(v, "projected")
+		for _, source := range listOfMaps(projected["sources"]) {
+			if cm, ok := nestedMap(source, "configMap"); ok {
+				add("ConfigMap", field, cm, "name")
+			}
+			if secret, ok := nestedMap(source, "secret"); ok {
+				add("Secret", field, secret, "name")
+			}
+		}
+	}
+
+	for _, key := range []string{"initContainers", "containers"} {
+		for _, c := range listOfMaps(spec[key]) {
+			field := fmt.Sprintf("container %q", containerName(c))
+			for _, env := range listOfMaps(c["env"]) {
+				if ref, ok := nestedMap(env, "valueFrom", "configMapKeyRef"); ok {
+					add("ConfigMap", field, ref, "name")
+				}
+				if ref, ok := nestedMap(env, "valueFrom", "secretKeyRef"); ok {
+					add("Secret", field, ref, "name")
+				}
+			}
+			for _, envFrom := range listOfMaps(c["envFrom"]) {
+				if ref, ok := nestedMap(envFrom, "configMapRef"); ok {
+					add("ConfigMap", field, ref, "name")
+				}
+				if ref, ok := nestedMap(envFrom, "secretRef"); ok {
+					add("Secret", field, ref, "name")
+				}
+			}
+		}
+	}
+
+	return refs
+}
+
+// listOfMaps returns the maps of a decoded yaml list, skipping other values
+func listOfMaps(v any) []map[string]any {
+	list, _ := v.([]any)
+	maps := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		if m, ok := asMap(item); ok {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
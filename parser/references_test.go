@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kdwils/splinter/pkg/fio/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+const referencesInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  template:
+    spec:
+      serviceAccountName: web
+      volumes:
+        - name: config
+          configMap:
+            name: web-config
+        - name: optional
+          secret:
+            secretName: maybe
+            optional: true
+        - name: data
+          persistentVolumeClaim:
+            claimName: web-data
+      containers:
+        - name: web
+          image: nginx:1.25
+          envFrom:
+            - secretRef:
+                name: web-env
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: prod
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-env
+  namespace: default
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+  namespace: prod
+spec:
+  rules:
+    - http:
+        paths:
+          - path: /
+            backend:
+              service:
+                name: web
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: web
+  namespace: prod
+roleRef:
+  kind: Role
+  name: web
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: viewers
+roleRef:
+  kind: ClusterRole
+  name: view
+`
+
+func Test_checkReferences(t *testing.T) {
+	docs := readDocuments("input.yaml", strings.NewReader(referencesInput))
+	resources := readResource(strings.NewReader(referencesInput))
+
+	want := []Finding{
+		{Rule: "dangling-reference", Severity: SeverityError, Source: "input.yaml", Line: 1, Resource: "Deployment/prod/web", Message: `serviceAccountName references ServiceAccount "web" which is not in the manifest set`},
+		{Rule: "dangling-reference", Severity: SeverityError, Source: "input.yaml", Line: 1, Resource: "Deployment/prod/web", Message: `volume "data" references PersistentVolumeClaim "web-data" which is not in the manifest set`},
+		{Rule: "dangling-reference", Severity: SeverityError, Source: "input.yaml", Line: 1, Resource: "Deployment/prod/web", Message: `container "web" references Secret "web-env" which is not in the manifest set`},
+		{Rule: "dangling-reference", Severity: SeverityError, Source: "input.yaml", Line: 40, Resource: "Ingress/prod/web", Message: `ingress backend references Service "web" which is not in the manifest set`},
+		{Rule: "dangling-reference", Severity: SeverityError, Source: "input.yaml", Line: 54, Resource: "RoleBinding/prod/web", Message: `roleRef references Role "web" which is not in the manifest set`},
+	}
+
+	if got := lint(docs, resources, []Rule{DanglingReferenceRule()}); !reflect.DeepEqual(got, want) {
+		t.Errorf("lint() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParser_Merge_withReferenceCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFio := mocks.NewMockFileIO(ctrl)
+	mockFio.EXPECT().ReadFile("input.yaml").Return([]byte(referencesInput), nil)
+
+	p := New(WithFileIO(mockFio), WithReferenceCheck(true))
+	err := p.Merge([]string{"input.yaml"}, nil, "output.yaml")
+
+	var dangling DanglingReferencesError
+	if !errors.As(err, &dangling) || len(dangling) != 5 {
+		t.Errorf("expected 5 dangling references, got %v", err)
+	}
+}
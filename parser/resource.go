@@ -2,10 +2,13 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 	"io"
 
 	"slices"
+	"strings"
 
+	"github.com/kdwils/splinter/pkg/fio"
 	"gopkg.in/yaml.v3"
 )
 
@@ -26,8 +29,84 @@ func (r Resource) Kind() (string, error) {
 	return k.(string), nil
 }
 
+// Name returns metadata.name of the resource, or an empty string if it is not set
+func (r Resource) Name() string {
+	name, _ := r.metadataString("name")
+	return name
+}
+
+// Namespace returns metadata.namespace of the resource, or an empty string if it is not set
+func (r Resource) Namespace() string {
+	namespace, _ := r.metadataString("namespace")
+	return namespace
+}
+
+// Ref returns a human readable reference to the resource in the form kind/namespace/name, omitting the namespace when unset
+func (r Resource) Ref() string {
+	kind, _ := r.Kind()
+	if ns := r.Namespace(); ns != "" {
+		return kind + "/" + ns + "/" + r.Name()
+	}
+	return kind + "/" + r.Name()
+}
+
+func (r Resource) metadataString(key string) (string, bool) {
+	metadata, ok := nestedMap(r, "metadata")
+	if !ok {
+		return "", false
+	}
+
+	v, ok := metadata[key].(string)
+	return v, ok
+}
+
+// metadataStrings returns a string map under metadata such as labels or annotations
+func (r Resource) metadataStrings(key string) map[string]string {
+	values, ok := nestedMap(r, "metadata", key)
+	if !ok || len(values) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(values))
+	for k, v := range values {
+		m[k] = fmt.Sprint(v)
+	}
+	return m
+}
+
+// nestedMap walks the given fields and returns the map found at the end of the path
+func nestedMap(m map[string]any, fields ...string) (map[string]any, bool) {
+	current := m
+	for _, f := range fields {
+		next, ok := asMap(current[f])
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+
+	return current, true
+}
+
+// asMap converts a decoded yaml value into a map. Nested maps decoded into a Resource keep the Resource type.
+func asMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case Resource:
+		return m, true
+	case map[string]any:
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
 func newKustomizeResource(resources ...string) Resource {
-	slices.Sort(resources)
+	slices.SortFunc(resources, func(a, b string) int {
+		if c := resourceOrder(a) - resourceOrder(b); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
 	return Resource{
 		"kind":       "Kustomization",
 		"apiVersion": "kustomize.config.k8s.io/v1beta1",
@@ -35,19 +114,79 @@ func newKustomizeResource(resources ...string) Resource {
 	}
 }
 
-func readResource(reader io.Reader) []Resource {
-	d := yaml.NewDecoder(reader)
-	rs := make([]Resource, 0)
+// document is a single yaml document along with the input it was read from
+type document struct {
+	source   string
+	index    int
+	node     *yaml.Node
+	resource Resource
 
-	var r Resource
-	for d.Decode(&r) == nil {
-		rs = append(rs, r)
-		r = make(Resource)
+	// files and path locate documents of archives and git revisions, see Stream
+	files fio.FileIO
+	path  string
+}
+
+// line returns the line the document's content starts on
+func (d document) line() int {
+	if len(d.node.Content) > 0 {
+		return d.node.Content[0].Line
+	}
+	return d.node.Line
+}
+
+func readResource(reader io.Reader) []Resource {
+	docs := readDocuments("", reader)
+	rs := make([]Resource, 0, len(docs))
+	for _, d := range docs {
+		rs = append(rs, d.resource)
 	}
 
 	return rs
 }
 
+// readDocuments decodes every document of a yaml stream, keeping the node tree for positional reporting
+func readDocuments(source string, reader io.Reader) []document {
+	docs := make([]document, 0)
+	decodeDocuments(source, reader, func(d document) error {
+		docs = append(docs, d)
+		return nil
+	})
+	return docs
+}
+
+// streamDocuments decodes every yaml document of a stream
+func streamDocuments(s Stream) []document {
+	docs := readDocuments(s.Name, s.Reader)
+	for i := range docs {
+		docs[i].files, docs[i].path = s.files, s.path
+	}
+	return docs
+}
+
+// decodeDocuments decodes the yaml documents of reader one at a time, stopping at the first it cannot decode
+func decodeDocuments(source string, reader io.Reader, fn func(document) error) error {
+	d := yaml.NewDecoder(reader)
+
+	for index := 0; ; index++ {
+		var node yaml.Node
+		if d.Decode(&node) != nil {
+			return nil
+		}
+
+		var r Resource
+		if node.Decode(&r) != nil {
+			return nil
+		}
+		if r == nil {
+			r = make(Resource)
+		}
+
+		if err := fn(document{source: source, index: index, node: &node, resource: r}); err != nil {
+			return err
+		}
+	}
+}
+
 func resourcesToMap(resources []Resource) map[string][]Resource {
 	m := make(map[string][]Resource)
 	for _, r := range resources {
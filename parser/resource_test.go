@@ -62,6 +62,17 @@ func Test_newKustomizeResource(t *testing.T) {
 				"resources":  []string{"a.yaml", "b.yaml", "c.yaml"},
 			},
 		},
+		{
+			name: "lists crds and cluster-scoped resources first",
+			args: args{
+				resources: []string{"service.yaml", "cluster/namespace.yaml", "crds/customresourcedefinition.yaml", "cluster/clusterrole.yaml"},
+			},
+			want: Resource{
+				"kind":       "Kustomization",
+				"apiVersion": "kustomize.config.k8s.io/v1beta1",
+				"resources":  []string{"crds/customresourcedefinition.yaml", "cluster/clusterrole.yaml", "cluster/namespace.yaml", "service.yaml"},
+			},
+		},
 		{
 			name: "empty resources",
 			args: args{
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	crdsDir    = "crds"
+	clusterDir = "cluster"
+)
+
+// clusterScopedKinds are the built-in kinds that are not namespaced
+var clusterScopedKinds = map[string]bool{
+	"APIService":                       true,
+	"CSIDriver":                        true,
+	"CSINode":                          true,
+	"CertificateSigningRequest":        true,
+	"ClusterRole":                      true,
+	"ClusterRoleBinding":               true,
+	"CustomResourceDefinition":         true,
+	"FlowSchema":                       true,
+	"IngressClass":                     true,
+	"MutatingWebhookConfiguration":     true,
+	"Namespace":                        true,
+	"Node":                             true,
+	"PersistentVolume":                 true,
+	"PodSecurityPolicy":                true,
+	"PriorityClass":                    true,
+	"PriorityLevelConfiguration":       true,
+	"RuntimeClass":                     true,
+	"StorageClass":                     true,
+	"ValidatingAdmissionPolicy":        true,
+	"ValidatingAdmissionPolicyBinding": true,
+	"ValidatingWebhookConfiguration":   true,
+	"VolumeAttachment":                 true,
+	"VolumeSnapshotClass":              true,
+}
+
+// WithSeparateCRDs writes CustomResourceDefinitions into a crds directory on split
+func WithSeparateCRDs(separate bool) ParserOpt {
+	return func(p *Parser) {
+		p.separateCRDs = separate
+	}
+}
+
+// WithSeparateClusterScoped writes cluster-scoped resources into a cluster directory on split
+func WithSeparateClusterScoped(separate bool) ParserOpt {
+	return func(p *Parser) {
+		p.separateClusterScoped = separate
+	}
+}
+
+// groupFiles groups resources by kind and keys each group by the file it is written to, relative to the output directory
+func (p *Parser) groupFiles(resources []Resource) map[string][]Resource {
+	clusterKinds := clusterScopedCustomKinds(resources)
+
+	files := make(map[string][]Resource)
+	for kind, rs := range resourcesToMap(resources) {
+		name := p.kindFile(kind, clusterKinds)
+		files[name] = append(files[name], rs...)
+	}
+
+	return files
+}
+
+func (p *Parser) kindFile(kind string, clusterKinds map[string]bool) string {
+	name := strings.ToLower(kind) + ".yaml"
+	switch {
+	case p.separateCRDs && kind == "CustomResourceDefinition":
+		return path.Join(crdsDir, name)
+	case p.separateClusterScoped && (clusterScopedKinds[kind] || clusterKinds[kind]):
+		return path.Join(clusterDir, name)
+	default:
+		return name
+	}
+}
+
+// clusterScopedCustomKinds returns the kinds defined by cluster-scoped CustomResourceDefinitions in the set
+func clusterScopedCustomKinds(resources []Resource) map[string]bool {
+	kinds := make(map[string]bool)
+	for _, r := range resources {
+		if kind, _ := r.Kind(); kind != "CustomResourceDefinition" {
+			continue
+		}
+
+		spec, ok := nestedMap(r, "spec")
+		if !ok || spec["scope"] != "Cluster" {
+			continue
+		}
+
+		names, ok := nestedMap(spec, "names")
+		if !ok {
+			continue
+		}
+
+		if kind, ok := names["kind"].(string); ok {
+			kinds[kind] = true
+		}
+	}
+
+	return kinds
+}
+
+// resourceOrder ranks kustomization resources so CRDs are applied before cluster-scoped resources and both before the rest
+func resourceOrder(name string) int {
+	switch {
+	case strings.HasPrefix(name, crdsDir+"/"):
+		return 0
+	case strings.HasPrefix(name, clusterDir+"/"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// sortedGroups returns the group names in the order they are applied, crds before cluster before the rest
+func sortedGroups(groups map[string][]string) []string {
+	names := make([]string, 0, len(groups))
+	for group := range groups {
+		names = append(names, group)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if c := resourceOrder(a+"/") - resourceOrder(b+"/"); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	return names
+}
+
+// groupName names the resource deploying a group, the output directory itself keeping the plain name
+func groupName(name, group string) string {
+	if group == "" {
+		return name
+	}
+	return name + "-" + group
+}
+
+// groupNamespace returns the namespace shared by every namespaced resource of a group, or an empty string
+func groupNamespace(names []string, files map[string][]Resource) string {
+	namespace := ""
+	for _, name := range names {
+		for _, r := range files[name] {
+			ns := r.Namespace()
+			switch {
+			case ns == "":
+				continue
+			case namespace == "":
+				namespace = ns
+			case namespace != ns:
+				return ""
+			}
+		}
+	}
+	return namespace
+}
+
+// outputGroups returns the files of the split output keyed by the directory they are written to, the output
+// directory itself being the empty group
+func outputGroups(files map[string][]Resource) map[string][]string {
+	groups := make(map[string][]string)
+	for name := range files {
+		group, _, nested := strings.Cut(name, "/")
+		if !nested {
+			group = ""
+		}
+		groups[group] = append(groups[group], name)
+	}
+	return groups
+}
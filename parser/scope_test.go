@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParser_groupFiles(t *testing.T) {
+	crd := Resource{
+		"kind":     "CustomResourceDefinition",
+		"metadata": Resource{"name": "issuers.example.com"},
+		"spec":     Resource{"scope": "Cluster", "names": Resource{"kind": "ClusterIssuer"}},
+	}
+	issuer := Resource{"kind": "ClusterIssuer", "metadata": Resource{"name": "letsencrypt"}}
+	namespace := Resource{"kind": "Namespace", "metadata": Resource{"name": "web"}}
+	service := Resource{"kind": "Service", "metadata": Resource{"name": "web"}}
+	resources := []Resource{crd, issuer, namespace, service}
+
+	tests := []struct {
+		name string
+		opts []ParserOpt
+		want map[string][]Resource
+	}{
+		{
+			name: "flat by default",
+			want: map[string][]Resource{
+				"customresourcedefinition.yaml": {crd},
+				"clusterissuer.yaml":            {issuer},
+				"namespace.yaml":                {namespace},
+				"service.yaml":                  {service},
+			},
+		},
+		{
+			name: "separate crds",
+			opts: []ParserOpt{WithSeparateCRDs(true)},
+			want: map[string][]Resource{
+				"crds/customresourcedefinition.yaml": {crd},
+				"clusterissuer.yaml":                 {issuer},
+				"namespace.yaml":                     {namespace},
+				"service.yaml":                       {service},
+			},
+		},
+		{
+			name: "separate cluster-scoped including custom kinds",
+			opts: []ParserOpt{WithSeparateClusterScoped(true)},
+			want: map[string][]Resource{
+				"cluster/customresourcedefinition.yaml": {crd},
+				"cluster/clusterissuer.yaml":            {issuer},
+				"cluster/namespace.yaml":                {namespace},
+				"service.yaml":                          {service},
+			},
+		},
+		{
+			name: "separate both",
+			opts: []ParserOpt{WithSeparateCRDs(true), WithSeparateClusterScoped(true)},
+			want: map[string][]Resource{
+				"crds/customresourcedefinition.yaml": {crd},
+				"cluster/clusterissuer.yaml":         {issuer},
+				"cluster/namespace.yaml":             {namespace},
+				"service.yaml":                       {service},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.opts...).groupFiles(resources); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
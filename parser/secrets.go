@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RedactedPlaceholder replaces every Secret value when redacting
+const RedactedPlaceholder = "REDACTED"
+
+var (
+	ErrPlainSecret = errors.New("refusing to write plain Secrets")
+)
+
+// WithSecretsDir writes Secrets into dir instead of the split output, leaving them out of the kustomization
+func WithSecretsDir(dir string) ParserOpt {
+	return func(p *Parser) {
+		p.secretsDir = dir
+	}
+}
+
+// WithFailOnSecrets fails split and merge when a Secret with unredacted, unencrypted data would be written to the output
+func WithFailOnSecrets(fail bool) ParserOpt {
+	return func(p *Parser) {
+		p.failOnSecrets = fail
+	}
+}
+
+// RedactSecretsTransform returns a transform replacing the values of every Secret's data and stringData with a placeholder, keeping the keys
+func RedactSecretsTransform() Transform {
+	encoded := base64.StdEncoding.EncodeToString([]byte(RedactedPlaceholder))
+	return func(resources []Resource) ([]Resource, error) {
+		for _, r := range resources {
+			if !isSecret(r) {
+				continue
+			}
+			if data, ok := asMap(r["data"]); ok {
+				for k := range data {
+					data[k] = encoded
+				}
+			}
+			if stringData, ok := asMap(r["stringData"]); ok {
+				for k := range stringData {
+					stringData[k] = RedactedPlaceholder
+				}
+			}
+		}
+
+		return resources, nil
+	}
+}
+
+func isSecret(r Resource) bool {
+	kind, _ := r.Kind()
+	return kind == "Secret" && (r["apiVersion"] == "v1" || r["apiVersion"] == nil)
+}
+
+// isPlainSecret reports whether a Secret carries a value that has not been redacted or encrypted
+func isPlainSecret(r Resource) bool {
+	if !isSecret(r) || isEncrypted(r) {
+		return false
+	}
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(RedactedPlaceholder))
+	data, _ := asMap(r["data"])
+	for _, v := range data {
+		if v != encoded && v != "" {
+			return true
+		}
+	}
+
+	stringData, _ := asMap(r["stringData"])
+	for _, v := range stringData {
+		if v != RedactedPlaceholder && v != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkPlainSecrets returns ErrPlainSecret naming every Secret with unredacted data when the guard is enabled
+func (p *Parser) checkPlainSecrets(resources []Resource) error {
+	if !p.failOnSecrets {
+		return nil
+	}
+
+	refs := make([]string, 0)
+	for _, r := range resources {
+		if isPlainSecret(r) {
+			refs = append(refs, r.Ref())
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrPlainSecret, strings.Join(refs, ", "))
+}
+
+// routeSecrets separates the Secrets written into the secrets directory from the remaining resources
+func (p *Parser) routeSecrets(resources []Resource) ([]Resource, []Resource) {
+	if p.secretsDir == "" {
+		return resources, nil
+	}
+
+	remaining := make([]Resource, 0, len(resources))
+	secrets := make([]Resource, 0)
+	for _, r := range resources {
+		if isSecret(r) {
+			secrets = append(secrets, r)
+			continue
+		}
+		remaining = append(remaining, r)
+	}
+
+	return remaining, secrets
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kdwils/splinter/pkg/fio"
+	"github.com/kdwils/splinter/pkg/fio/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRedactSecretsTransform(t *testing.T) {
+	resources := []Resource{
+		{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"data":       Resource{"password": "aHVudGVyMg=="},
+			"stringData": Resource{"user": "admin"},
+		},
+		{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data":       Resource{"user": "admin"},
+		},
+	}
+
+	want := []Resource{
+		{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"data":       Resource{"password": "UkVEQUNURUQ="},
+			"stringData": Resource{"user": RedactedPlaceholder},
+		},
+		{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data":       Resource{"user": "admin"},
+		},
+	}
+
+	got, err := RedactSecretsTransform()(resources)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactSecretsTransform() = %v, want %v", got, want)
+	}
+
+	for _, r := range got {
+		if isPlainSecret(r) {
+			t.Errorf("expected %v not to be a plain secret after redaction", r)
+		}
+	}
+}
+
+func TestParser_Merge_failOnSecrets(t *testing.T) {
+	input := []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\nstringData:\n  password: hunter2\n")
+
+	t.Run("plain secret", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFio := mocks.NewMockFileIO(ctrl)
+		mockFio.EXPECT().ReadFile("input.yaml").Return(input, nil)
+
+		p := New(WithFileIO(mockFio), WithFailOnSecrets(true))
+		if err := p.Merge([]string{"input.yaml"}, nil, "output.yaml"); !errors.Is(err, ErrPlainSecret) {
+			t.Errorf("expected %v, got %v", ErrPlainSecret, err)
+		}
+	})
+
+	t.Run("redacted secret", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFio := mocks.NewMockFileIO(ctrl)
+		mockFile := mocks.NewMockWriteCloser(ctrl)
+		mockFio.EXPECT().ReadFile("input.yaml").Return(input, nil)
+		mockFio.EXPECT().Stat(".").Return(nil, nil)
+		mockFio.EXPECT().Create("output.yaml").Return(mockFile, nil)
+		mockFile.EXPECT().Write(gomock.Any()).Return(0, nil)
+		mockFile.EXPECT().Close().Return(nil)
+
+		p := New(WithFileIO(mockFio), WithFailOnSecrets(true), WithTransforms(RedactSecretsTransform()))
+		if err := p.Merge([]string{"input.yaml"}, nil, "output.yaml"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
+
+func TestParser_Split_secretsDir(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFio := mocks.NewMockFileIO(ctrl)
+	mockSecretFile := mocks.NewMockWriteCloser(ctrl)
+	mockServiceFile := mocks.NewMockWriteCloser(ctrl)
+	mockKustomizeFile := mocks.NewMockWriteCloser(ctrl)
+
+	input := []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n")
+	kustomization := []byte("apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n  - service.yaml\n")
+
+	mockFio.EXPECT().ReadFile("input.yaml").Return(input, nil)
+	mockFio.EXPECT().Stat("secrets").Return(nil, os.ErrNotExist)
+	mockFio.EXPECT().MkdirAll("secrets", os.ModePerm).Return(nil)
+	mockFio.EXPECT().Create("secrets/secret.yaml").Return(mockSecretFile, nil)
+	mockSecretFile.EXPECT().Write(gomock.Any()).Return(0, nil)
+	mockSecretFile.EXPECT().Close().Return(nil)
+
+	mockFio.EXPECT().Stat("output").Return(nil, nil).Times(2)
+	mockFio.EXPECT().Create("output/service.yaml").Return(mockServiceFile, nil)
+	mockServiceFile.EXPECT().Write(gomock.Any()).Return(0, nil)
+	mockServiceFile.EXPECT().Close().Return(nil)
+	mockFio.EXPECT().Create("output/kustomization.yaml").Return(mockKustomizeFile, nil)
+	mockKustomizeFile.EXPECT().Write(kustomization).Return(0, nil)
+	mockKustomizeFile.EXPECT().Close().Return(nil)
+
+	p := New(WithFileIO(mockFio), WithSecretsDir("secrets"), WithFailOnSecrets(true))
+	if err := p.Split([]string{"input.yaml"}, nil, "output", true); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestParser_Split_secretsDirPlainSecret(t *testing.T) {
+	m := fio.NewMemory()
+	m.AddFile("input.yaml", []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\nstringData:\n  password: hunter2\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n"))
+
+	p := New(WithFileIO(m), WithSecretsDir("secrets"), WithFailOnSecrets(true))
+	if err := p.Split([]string{"input.yaml"}, nil, "output", true); !errors.Is(err, ErrPlainSecret) {
+		t.Errorf("expected %v, got %v", ErrPlainSecret, err)
+	}
+	if files := m.Files(); !reflect.DeepEqual(files, []string{"input.yaml"}) {
+		t.Errorf("expected nothing to be written, got %v", files)
+	}
+}
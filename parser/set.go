@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"slices"
+)
+
+// ResourceSet is an ordered set of resources, as returned by Read and consumed by Write. Its methods return new sets
+// and leave the receiver unchanged, though the resources themselves are shared.
+type ResourceSet struct {
+	resources []Resource
+}
+
+// NewResourceSet returns a set holding the resources in the given order
+func NewResourceSet(resources ...Resource) ResourceSet {
+	return ResourceSet{resources: slices.Clone(resources)}
+}
+
+// Resources returns the resources of the set in order
+func (s ResourceSet) Resources() []Resource {
+	return slices.Clone(s.resources)
+}
+
+// Len returns the number of resources in the set
+func (s ResourceSet) Len() int {
+	return len(s.resources)
+}
+
+// Filter returns the resources for which keep returns true
+func (s ResourceSet) Filter(keep func(r Resource) bool) ResourceSet {
+	filtered := make([]Resource, 0, len(s.resources))
+	for _, r := range s.resources {
+		if keep(r) {
+			filtered = append(filtered, r)
+		}
+	}
+	return ResourceSet{resources: filtered}
+}
+
+// Transform applies the transforms in order and returns the resulting set
+func (s ResourceSet) Transform(transforms ...Transform) (ResourceSet, error) {
+	resources := slices.Clone(s.resources)
+
+	var err error
+	for _, t := range transforms {
+		resources, err = t(resources)
+		if err != nil {
+			return ResourceSet{}, err
+		}
+	}
+
+	return ResourceSet{resources: resources}, nil
+}
+
+// Group splits the set by the key of each resource, keeping the order of the resources within each group
+func (s ResourceSet) Group(key func(r Resource) string) map[string]ResourceSet {
+	groups := make(map[string]ResourceSet)
+	for _, r := range s.resources {
+		k := key(r)
+		g := groups[k]
+		g.resources = append(g.resources, r)
+		groups[k] = g
+	}
+	return groups
+}
+
+// OfKind returns a filter keeping resources of any of the given kinds
+func OfKind(kinds ...string) func(r Resource) bool {
+	return func(r Resource) bool {
+		kind, _ := r["kind"].(string)
+		return slices.Contains(kinds, kind)
+	}
+}
+
+// InNamespace returns a filter keeping resources in the given namespace
+func InNamespace(namespace string) func(r Resource) bool {
+	return func(r Resource) bool {
+		return r.Namespace() == namespace
+	}
+}
+
+// ByKind is a Group key grouping resources by kind
+func ByKind(r Resource) string {
+	kind, _ := r["kind"].(string)
+	return kind
+}
+
+// ByNamespace is a Group key grouping resources by namespace
+func ByNamespace(r Resource) string {
+	return r.Namespace()
+}
@@ -0,0 +1,320 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kdwils/splinter/pkg/fio"
+	"github.com/kdwils/splinter/pkg/schema"
+)
+
+var (
+	ErrStreamUnsupported = errors.New("not supported when streaming")
+	ErrStreamOrder       = errors.New("a cluster-scoped CustomResourceDefinition must come before its resources when streaming")
+)
+
+// SplitStream splits like Split while holding a single document in memory at a time. Documents are decoded as they
+// are read and written straight to the file of their kind, so memory use stays flat however large the input is.
+// Local files are read incrementally, while archives, git revisions and URLs are read whole.
+//
+// Transforms run on one resource at a time, and options needing every resource at once, such as extracting data,
+// checking references, hoisting namespaces and the Argo CD and Flux resources, fail with ErrStreamUnsupported. Files
+// written before an error are left in place.
+func (p *Parser) SplitStream(inputFiles []string, stdin io.Reader, outputPath string, kustomize bool) error {
+	if err := p.checkStreaming(true); err != nil {
+		return err
+	}
+
+	w := &streamWriter{p: p, files: make(map[string]*streamFile)}
+	// clusterKinds are the kinds of the cluster-scoped CustomResourceDefinitions read so far, namespaced the kinds
+	// already written outside the cluster directory
+	clusterKinds := make(map[string]bool)
+	namespaced := make(map[string]bool)
+	names := make([]string, 0)
+
+	err := p.eachDocument(inputFiles, stdin, p.streamSchemas(), func(r Resource) error {
+		kind, _ := r.Kind()
+		if strings.EqualFold(kind, "kustomization") {
+			return nil
+		}
+
+		if err := p.checkPlainSecrets([]Resource{r}); err != nil {
+			return err
+		}
+		if p.secretsDir != "" && isSecret(r) {
+			return w.write(path.Join(p.secretsDir, "secret.yaml"), r)
+		}
+
+		if p.separateClusterScoped {
+			for k := range clusterScopedCustomKinds([]Resource{r}) {
+				if namespaced[k] {
+					return fmt.Errorf("%w: %s", ErrStreamOrder, k)
+				}
+				clusterKinds[k] = true
+			}
+		}
+
+		name := p.kindFile(kind, clusterKinds)
+		if !strings.HasPrefix(name, clusterDir+"/") {
+			namespaced[kind] = true
+		}
+		if !w.has(path.Join(outputPath, name)) {
+			names = append(names, name)
+		}
+		return w.write(path.Join(outputPath, name), r)
+	})
+	if closeErr := w.close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || !kustomize {
+		return err
+	}
+
+	fileName, err := p.kustomizationFile()
+	if err != nil {
+		return err
+	}
+	k := newKustomizeResource(names...)
+	p.applyKustomizationOptions(k, nil, clusterKinds)
+
+	files := map[string][]Resource{fileName: {k}}
+	if p.updateKustomization {
+		if err := p.updateKustomizations(outputPath, files); err != nil {
+			return err
+		}
+	}
+	for name, rs := range files {
+		if err := p.write(path.Join(outputPath, name), p.indentSize, rs...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MergeStream merges like Merge while holding a single document in memory at a time, writing every resource to
+// the output as soon as it is read. Transforms run on one resource at a time.
+func (p *Parser) MergeStream(files []string, stdin io.Reader, outputPath string) error {
+	if err := p.checkStreaming(false); err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if outputPath != "" {
+		if err := p.mkdir(path.Dir(outputPath)); err != nil {
+			return err
+		}
+		f, err := p.fio.Create(outputPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	e := &documentEncoder{w: out, indent: p.indentSize}
+	return p.eachDocument(files, stdin, p.streamSchemas(), func(r Resource) error {
+		if err := p.checkPlainSecrets([]Resource{r}); err != nil {
+			return err
+		}
+		return e.encode(r)
+	})
+}
+
+// checkStreaming fails for the options that need every resource in memory
+func (p *Parser) checkStreaming(split bool) error {
+	var option string
+	switch {
+	case p.extractData:
+		option = "extracting data"
+	case p.checkReferences:
+		option = "checking references"
+	case split && p.kustomization.HoistNamespace:
+		option = "hoisting namespaces"
+	case split && p.argoCD != nil:
+		option = "Argo CD applications"
+	case split && p.flux != nil:
+		option = "Flux Kustomizations"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%s is %w", option, ErrStreamUnsupported)
+}
+
+// streamSchemas returns the schemas documents are validated against while streaming, which gain the
+// CustomResourceDefinitions read along the way
+func (p *Parser) streamSchemas() *schema.Set {
+	if p.schemas == nil {
+		return nil
+	}
+	return p.schemas.Clone()
+}
+
+// eachDocument decodes the documents of stdin and the inputs one at a time, passing every resource with a kind to
+// fn once it is decrypted, validated and transformed
+func (p *Parser) eachDocument(inputs []string, stdin io.Reader, schemas *schema.Set, fn func(Resource) error) error {
+	handle := func(d document) error {
+		docs := []document{d}
+		if err := p.decrypt(docs); err != nil {
+			return err
+		}
+		if schemas != nil {
+			if errs := validateDocuments(schemas, docs); len(errs) > 0 {
+				return errs
+			}
+		}
+		if _, err := docs[0].resource.Kind(); err != nil {
+			return nil
+		}
+
+		resources, err := p.transform([]Resource{docs[0].resource})
+		if err != nil {
+			return err
+		}
+		for _, r := range resources {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if stdin != nil {
+		if err := decodeDocuments(stdinSource, stdin, handle); err != nil {
+			return err
+		}
+	}
+
+	for _, input := range inputs {
+		if isGitInput(input) {
+			streams, err := p.gitStreams(input)
+			if err != nil {
+				return err
+			}
+			if err := decodeStreams(streams, handle); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, f := range p.filesFromInput([]string{input}) {
+			if ArchiveFormat(f) != "" {
+				streams, err := p.archiveStreams(f)
+				if err != nil {
+					return err
+				}
+				if err := decodeStreams(streams, handle); err != nil {
+					return err
+				}
+				continue
+			}
+
+			r, err := fio.Open(p.fio, f)
+			if err != nil {
+				return err
+			}
+			err = decodeDocuments(f, r, handle)
+			r.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func decodeStreams(streams []Stream, fn func(document) error) error {
+	for _, s := range streams {
+		if err := decodeDocuments(s.Name, s.Reader, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mkdir creates a directory along with its parents when it does not exist
+func (p *Parser) mkdir(dir string) error {
+	if _, err := p.fio.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return p.fio.MkdirAll(dir, os.ModePerm)
+	}
+	return nil
+}
+
+// streamWriter keeps every file written while streaming open
+type streamWriter struct {
+	p     *Parser
+	files map[string]*streamFile
+	order []string
+}
+
+type streamFile struct {
+	w io.WriteCloser
+	e *documentEncoder
+}
+
+// documentEncoder writes resources to w as a yaml stream. Every document gets its own yaml.Encoder, which keeps the
+// events of everything it has emitted until it is closed.
+type documentEncoder struct {
+	w       io.Writer
+	indent  int
+	written bool
+}
+
+func (d *documentEncoder) encode(r Resource) error {
+	if d.written {
+		if _, err := io.WriteString(d.w, "---\n"); err != nil {
+			return err
+		}
+	}
+	d.written = true
+
+	e := yaml.NewEncoder(d.w)
+	e.SetIndent(d.indent)
+	if err := e.Encode(r); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+func (w *streamWriter) has(name string) bool {
+	_, ok := w.files[name]
+	return ok
+}
+
+// write appends a resource to the named file, creating it on first use
+func (w *streamWriter) write(name string, r Resource) error {
+	f, ok := w.files[name]
+	if !ok {
+		if err := w.p.mkdir(path.Dir(name)); err != nil {
+			return err
+		}
+		out, err := w.p.fio.Create(name)
+		if err != nil {
+			return err
+		}
+
+		f = &streamFile{w: out, e: &documentEncoder{w: out, indent: w.p.indentSize}}
+		w.files[name] = f
+		w.order = append(w.order, name)
+	}
+
+	return f.e.encode(r)
+}
+
+// close closes every file, returning the first error
+func (w *streamWriter) close() error {
+	var err error
+	for _, name := range w.order {
+		if e := w.files[name].w.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
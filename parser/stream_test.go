@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kdwils/splinter/pkg/fio"
+)
+
+const streamInput = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clusterissuers.cert-manager.io
+spec:
+  group: cert-manager.io
+  scope: Cluster
+  names:
+    kind: ClusterIssuer
+    plural: clusterissuers
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: cert-manager
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cert-manager
+  namespace: cert-manager
+---
+apiVersion: cert-manager.io/v1
+kind: ClusterIssuer
+metadata:
+  name: letsencrypt
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: webhook
+  namespace: cert-manager
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: token
+  namespace: cert-manager
+data:
+  token: dG9rZW4=
+`
+
+func TestParser_SplitStream(t *testing.T) {
+	opts := func(m *fio.Memory) []ParserOpt {
+		return []ParserOpt{
+			WithFileIO(m),
+			WithSeparateCRDs(true),
+			WithSeparateClusterScoped(true),
+			WithKustomization(KustomizationOptions{Namespace: "cert-manager", Labels: map[string]string{"team": "platform"}}),
+			WithTransforms(ImageOverrideTransform()),
+		}
+	}
+
+	split := fio.NewMemory()
+	split.AddFile("in/install.yaml", []byte(streamInput))
+	if err := New(opts(split)...).Split([]string{"in"}, nil, "out", true); err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+
+	streamed := fio.NewMemory()
+	streamed.AddFile("in/install.yaml", []byte(streamInput))
+	if err := New(opts(streamed)...).SplitStream([]string{"in"}, nil, "out", true); err != nil {
+		t.Fatalf("SplitStream() error = %v", err)
+	}
+
+	want := split.FilesIn("out")
+	if got := streamed.FilesIn("out"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SplitStream() wrote %v, want %v", got, want)
+	}
+	for _, name := range want {
+		got, _ := streamed.ReadFile("out/" + name)
+		want, _ := split.ReadFile("out/" + name)
+		if string(got) != string(want) {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestParser_SplitStream_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    []ParserOpt
+		wantErr error
+	}{
+		{
+			name:    "cluster-scoped resource before its definition",
+			input:   streamInput[strings.Index(streamInput, "---\n")+4:] + "---\n" + streamInput[:strings.Index(streamInput, "---\n")],
+			opts:    []ParserOpt{WithSeparateClusterScoped(true)},
+			wantErr: ErrStreamOrder,
+		},
+		{
+			name:    "reference check",
+			input:   streamInput,
+			opts:    []ParserOpt{WithReferenceCheck(true)},
+			wantErr: ErrStreamUnsupported,
+		},
+		{
+			name:    "plain secrets",
+			input:   streamInput,
+			opts:    []ParserOpt{WithFailOnSecrets(true)},
+			wantErr: ErrPlainSecret,
+		},
+		{
+			name:    "plain secrets with a secrets directory",
+			input:   streamInput,
+			opts:    []ParserOpt{WithFailOnSecrets(true), WithSecretsDir("secrets")},
+			wantErr: ErrPlainSecret,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := fio.NewMemory()
+			p := New(append([]ParserOpt{WithFileIO(m)}, tt.opts...)...)
+			if err := p.SplitStream(nil, strings.NewReader(tt.input), "out", false); !errors.Is(err, tt.wantErr) {
+				t.Errorf("SplitStream() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, ok := m.Contents("secrets/secret.yaml"); ok {
+				t.Error("expected no Secret to be written")
+			}
+		})
+	}
+}
+
+func TestParser_MergeStream(t *testing.T) {
+	m := fio.NewMemory()
+	m.AddFile("in/a.yaml", []byte(streamInput))
+	m.AddFile("in/b.yaml", []byte("kind: Kustomization\nresources:\n  - a.yaml\n"))
+	m.MkdirAll("out", 0o755)
+
+	p := New(WithFileIO(m), WithTransforms(RedactSecretsTransform()))
+	if err := p.Merge([]string{"in"}, nil, "out/merged.yaml"); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if err := p.MergeStream([]string{"in"}, nil, "out/streamed.yaml"); err != nil {
+		t.Fatalf("MergeStream() error = %v", err)
+	}
+
+	merged, _ := m.ReadFile("out/merged.yaml")
+	streamed, _ := m.ReadFile("out/streamed.yaml")
+	if string(streamed) != string(merged) {
+		t.Errorf("MergeStream() = %s, want %s", streamed, merged)
+	}
+}
+
+// generatedInput is a yaml stream of n ConfigMaps produced as it is read
+type generatedInput struct {
+	n, i int
+	buf  strings.Reader
+}
+
+func (g *generatedInput) Read(b []byte) (int, error) {
+	if g.buf.Len() == 0 {
+		if g.i == g.n {
+			return 0, io.EOF
+		}
+		g.buf.Reset(fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config-%d\ndata:\n  value: %q\n---\n", g.i, strings.Repeat("x", 1024)))
+		g.i++
+	}
+	return g.buf.Read(b)
+}
+
+func TestParser_SplitStream_generatedInput(t *testing.T) {
+	m := fio.NewMemory()
+	if err := New(WithFileIO(m)).SplitStream(nil, &generatedInput{n: 5000}, "out", false); err != nil {
+		t.Fatalf("SplitStream() error = %v", err)
+	}
+
+	b, _ := m.ReadFile("out/configmap.yaml")
+	if got := strings.Count(string(b), "kind: ConfigMap"); got != 5000 {
+		t.Errorf("wrote %d ConfigMaps, want 5000", got)
+	}
+}
@@ -0,0 +1,4 @@
+package parser
+
+// Transform rewrites a set of resources after they are read and before they are written
+type Transform func(resources []Resource) ([]Resource, error)
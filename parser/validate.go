@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kdwils/splinter/pkg/schema"
+)
+
+const stdinSource = "<stdin>"
+
+// ValidationError is a schema violation found in an input document
+type ValidationError struct {
+	Source   string
+	Document int
+	Resource string
+	schema.Violation
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s:%d:%d (document %d) %s: %s", e.Source, e.Line, e.Column, e.Document+1, e.Resource, e.Violation.Error())
+}
+
+// ValidationErrors is every schema violation found while reading input
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return fmt.Sprintf("%d schema violations:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// WithSchemas validates every input document against the schema set while reading.
+// CustomResourceDefinitions present in the input are added to a copy of the set before validating.
+func WithSchemas(schemas *schema.Set) ParserOpt {
+	return func(p *Parser) {
+		p.schemas = schemas
+	}
+}
+
+// Validate reads the input and returns every schema violation. It uses the bundled schemas for
+// schema.DefaultVersion unless the parser was created WithSchemas.
+func (p *Parser) Validate(inputFiles []string, stdin io.Reader) (ValidationErrors, error) {
+	if p.schemas == nil {
+		schemas, err := schema.Load(schema.DefaultVersion)
+		if err != nil {
+			return nil, err
+		}
+		p.schemas = schemas
+	}
+
+	docs, err := p.readDocuments(inputFiles, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.validate(docs), nil
+}
+
+func (p *Parser) validate(docs []document) ValidationErrors {
+	return validateDocuments(p.schemas.Clone(), docs)
+}
+
+// validateDocuments validates documents against schemas, adding the schemas of the CustomResourceDefinitions among them
+func validateDocuments(schemas *schema.Set, docs []document) ValidationErrors {
+	var errs ValidationErrors
+	for _, d := range docs {
+		if kind, _ := d.resource.Kind(); kind != "CustomResourceDefinition" {
+			continue
+		}
+		if err := schemas.AddCRD(d.resource); err != nil {
+			errs = append(errs, ValidationError{
+				Source:    d.source,
+				Document:  d.index,
+				Resource:  d.resource.Ref(),
+				Violation: schema.Violation{Path: ".spec", Line: d.line(), Column: 1, Message: err.Error()},
+			})
+		}
+	}
+
+	for _, d := range docs {
+		violations, _ := schemas.Validate(d.node)
+		for _, v := range violations {
+			errs = append(errs, ValidationError{
+				Source:    d.source,
+				Document:  d.index,
+				Resource:  d.resource.Ref(),
+				Violation: v,
+			})
+		}
+	}
+
+	return errs
+}
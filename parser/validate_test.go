@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kdwils/splinter/pkg/fio/mocks"
+	"github.com/kdwils/splinter/pkg/schema"
+	"go.uber.org/mock/gomock"
+)
+
+const invalidInput = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names: {kind: Widget, plural: widgets}
+  versions:
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              properties:
+                size: {type: integer}
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+  namespace: prod
+spec:
+  size: large
+`
+
+func TestParser_Validate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFio := mocks.NewMockFileIO(ctrl)
+	mockFio.EXPECT().ReadFile("input.yaml").Return([]byte(invalidInput), nil)
+
+	want := ValidationErrors{
+		{
+			Source:    "input.yaml",
+			Document:  2,
+			Resource:  "Widget/prod/w",
+			Violation: schema.Violation{Path: ".spec.size", Line: 33, Column: 9, Message: `expected integer, got string "large"`},
+		},
+	}
+
+	p := New(WithFileIO(mockFio))
+	got, err := p.Validate([]string{"input.yaml"}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParser_Merge_withSchemas(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFio := mocks.NewMockFileIO(ctrl)
+	mockFio.EXPECT().ReadFile("input.yaml").Return([]byte(invalidInput), nil)
+
+	schemas, err := schema.Load(schema.DefaultVersion)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	p := New(WithFileIO(mockFio), WithSchemas(schemas))
+	err = p.Merge([]string{"input.yaml"}, nil, "output.yaml")
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Errorf("expected a single validation error, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the contents of the splinter config file
+type Config struct {
+	Lint   Lint   `yaml:"lint"`
+	Check  Check  `yaml:"check"`
+	ArgoCD ArgoCD `yaml:"argocd"`
+	Flux   Flux   `yaml:"flux"`
+	// Plugins transform resources between reading and writing on split and merge, in order
+	Plugins []Plugin `yaml:"plugins"`
+}
+
+// Lint configures the lint command
+type Lint struct {
+	// Rules enables or disables rules by id. Rules not listed are enabled, except for optional rules.
+	Rules map[string]bool `yaml:"rules"`
+}
+
+// Enabled reports whether the lint rule with the given id should run
+func (l Lint) Enabled(id string) bool {
+	enabled, ok := l.Rules[id]
+	return !ok || enabled
+}
+
+// EnabledOptional reports whether an optional lint rule, which is off unless listed, should run
+func (l Lint) EnabledOptional(id string) bool {
+	return l.Rules[id]
+}
+
+// Check configures the check command
+type Check struct {
+	// Policies are paths to policy files evaluated in addition to those passed with --policy
+	Policies []string `yaml:"policies"`
+}
+
+// ArgoCD holds the defaults for the Argo CD resources generated by split. Flags take precedence.
+type ArgoCD struct {
+	Namespace      string `yaml:"namespace"`
+	RepoURL        string `yaml:"repoURL"`
+	TargetRevision string `yaml:"targetRevision"`
+	Server         string `yaml:"server"`
+	Project        string `yaml:"project"`
+}
+
+// Flux holds the defaults for the Flux Kustomizations generated by split. Flags take precedence.
+type Flux struct {
+	Namespace string        `yaml:"namespace"`
+	SourceRef FluxSourceRef `yaml:"sourceRef"`
+	Interval  string        `yaml:"interval"`
+	Prune     *bool         `yaml:"prune"`
+	DependsOn []string      `yaml:"dependsOn"`
+}
+
+// FluxSourceRef is the source the Flux Kustomizations read from
+type FluxSourceRef struct {
+	Kind      string `yaml:"kind"`
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+// Plugin is an executable receiving resources on stdin and writing the transformed set to stdout
+type Plugin struct {
+	Name    string            `yaml:"name"`
+	Command string            `yaml:"command"`
+	Args    []string          `yaml:"args"`
+	Env     map[string]string `yaml:"env"`
+	// Format is stream for a yaml stream or resourceList for a KRM ResourceList
+	Format         string         `yaml:"format"`
+	FunctionConfig map[string]any `yaml:"functionConfig"`
+}
+
+// DefaultPath returns $HOME/.splinter.yaml
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".splinter.yaml")
+}
+
+// Load reads the config file at path. A missing file at the default path yields an empty config.
+func Load(path string) (Config, error) {
+	var c Config
+
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath()
+	}
+	if path == "" {
+		return c, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	err = yaml.Unmarshal(b, &c)
+	return c, err
+}
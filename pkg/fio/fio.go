@@ -1,6 +1,8 @@
 package fio
 
 import (
+	"bytes"
+	"errors"
 	"io"
 	"io/fs"
 	"os"
@@ -16,6 +18,38 @@ type FileIO interface {
 	WriteFile(filename string, data []byte, perm fs.FileMode) error
 }
 
+// Opener is implemented by a FileIO that can open a file for reading without loading it whole
+type Opener interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+// Open opens the named file for reading through fileIO, reading it whole when fileIO does not implement Opener
+func Open(fileIO FileIO, name string) (io.ReadCloser, error) {
+	if o, ok := fileIO.(Opener); ok {
+		return o.Open(name)
+	}
+
+	b, err := fileIO.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// Remover is implemented by a FileIO that can remove files
+type Remover interface {
+	Remove(name string) error
+}
+
+// Remove removes the named file through fileIO, failing with errors.ErrUnsupported when fileIO does not implement
+// Remover
+func Remove(fileIO FileIO, name string) error {
+	if r, ok := fileIO.(Remover); ok {
+		return r.Remove(name)
+	}
+	return &fs.PathError{Op: "remove", Path: name, Err: errors.ErrUnsupported}
+}
+
 // WriteCloser is an alias for io.WriteCloser
 type WriteCloser io.WriteCloser
 
@@ -35,6 +69,11 @@ func (fsys DefaultFS) ReadFile(filename string) ([]byte, error) {
 	return os.ReadFile(filename)
 }
 
+// Open is a wrapper around os.Open
+func (fsys DefaultFS) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
 // Stat is a wrapper around os.Stat
 func (fsys DefaultFS) Stat(name string) (fs.FileInfo, error) {
 	return os.Stat(name)
@@ -55,6 +94,11 @@ func (fsys DefaultFS) Create(name string) (io.WriteCloser, error) {
 	return os.Create(name)
 }
 
+// Remove is a wrapper around os.Remove
+func (fsys DefaultFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
 // MkdirAll is a wrapper around os.MkdirAll
 func (fsys DefaultFS) MkdirAll(path string, perm fs.FileMode) error {
 	return os.MkdirAll(path, perm)
@@ -0,0 +1,74 @@
+package fio
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ErrReadOnly is returned when writing through a FileIO backed by a read-only fs.FS
+var ErrReadOnly = errors.New("file system is read-only")
+
+var _ FileIO = FS{}
+
+// FS is a read-only FileIO over an fs.FS such as an embed.FS or fstest.MapFS. Paths are resolved relative to the
+// root of the fs.FS, so "./manifests" and "/manifests" both name "manifests".
+type FS struct {
+	fsys fs.FS
+}
+
+// NewFS returns a FileIO reading from fsys
+func NewFS(fsys fs.FS) FileIO {
+	return FS{fsys: fsys}
+}
+
+// ReadFile reads the named file from the fs.FS
+func (f FS) ReadFile(filename string) ([]byte, error) {
+	return fs.ReadFile(f.fsys, fsPath(filename))
+}
+
+// Open opens the named file of the fs.FS for reading
+func (f FS) Open(name string) (io.ReadCloser, error) {
+	return f.fsys.Open(fsPath(name))
+}
+
+// Stat returns the fs.FileInfo of the named file in the fs.FS
+func (f FS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.fsys, fsPath(name))
+}
+
+// ReadDir reads the named directory of the fs.FS
+func (f FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(f.fsys, fsPath(name))
+}
+
+// Create fails with ErrReadOnly
+func (f FS) Create(name string) (io.WriteCloser, error) {
+	return nil, &fs.PathError{Op: "create", Path: name, Err: ErrReadOnly}
+}
+
+// MkdirAll fails with ErrReadOnly
+func (f FS) MkdirAll(name string, perm fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: ErrReadOnly}
+}
+
+// WriteFile fails with ErrReadOnly
+func (f FS) WriteFile(filename string, data []byte, perm fs.FileMode) error {
+	return &fs.PathError{Op: "write", Path: filename, Err: ErrReadOnly}
+}
+
+// Remove fails with ErrReadOnly
+func (f FS) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+}
+
+// fsPath turns an os style path into a path valid for an fs.FS
+func fsPath(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
@@ -0,0 +1,33 @@
+package fio
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFS(t *testing.T) {
+	f := NewFS(fstest.MapFS{
+		"manifests/deployment.yaml": {Data: []byte("kind: Deployment\n")},
+	})
+
+	b, err := f.ReadFile("./manifests/deployment.yaml")
+	if err != nil || string(b) != "kind: Deployment\n" {
+		t.Errorf("ReadFile() = %q, %v", b, err)
+	}
+	if info, err := f.Stat("manifests"); err != nil || !info.IsDir() {
+		t.Errorf("Stat() = %v, %v, want a directory", info, err)
+	}
+	if entries, err := f.ReadDir("/manifests"); err != nil || len(entries) != 1 {
+		t.Errorf("ReadDir() = %v, %v, want one entry", entries, err)
+	}
+	if err := f.WriteFile("out.yaml", nil, 0o644); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("WriteFile() error = %v, want %v", err, ErrReadOnly)
+	}
+	if _, err := f.Create("out.yaml"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Create() error = %v, want %v", err, ErrReadOnly)
+	}
+	if err := Remove(f, "out.yaml"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Remove() error = %v, want %v", err, ErrReadOnly)
+	}
+}
@@ -0,0 +1,338 @@
+package fio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const checksumPrefix = "sha256="
+
+// DefaultTimeout bounds every request, including reading the response, so a stalled server fails the read
+const DefaultTimeout = 2 * time.Minute
+
+var (
+	ErrNotCached        = errors.New("not in the http cache")
+	ErrChecksumMismatch = errors.New("sha256 checksum mismatch")
+	ErrInvalidChecksum  = errors.New("checksum must be #sha256=<64 hex characters>")
+	ErrFetch            = errors.New("fetch failed")
+)
+
+var _ FileIO = (*HTTP)(nil)
+
+// HTTP is a FileIO reading http and https URLs through an on-disk cache and passing every other path to the FileIO
+// it wraps. Cached files are revalidated with their ETag, and a URL ending in #sha256=<hex> is only accepted when
+// its contents have that checksum, which also lets a pinned URL be served from the cache without a request.
+type HTTP struct {
+	FileIO
+	client   *http.Client
+	cacheDir string
+	offline  bool
+}
+
+type HTTPOpt func(h *HTTP)
+
+// NewHTTP returns a FileIO reading URLs over http and everything else from local
+func NewHTTP(local FileIO, opts ...HTTPOpt) *HTTP {
+	h := &HTTP{
+		FileIO:   local,
+		client:   &http.Client{Timeout: DefaultTimeout},
+		cacheDir: DefaultCacheDir(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// WithHTTPClient sets the client URLs are fetched with
+func WithHTTPClient(client *http.Client) HTTPOpt {
+	return func(h *HTTP) {
+		h.client = client
+	}
+}
+
+// WithTimeout sets how long a request, including reading the response, may take. Zero means no limit.
+func WithTimeout(timeout time.Duration) HTTPOpt {
+	return func(h *HTTP) {
+		c := *h.client
+		c.Timeout = timeout
+		h.client = &c
+	}
+}
+
+// WithCacheDir sets the directory fetched files are cached in. An empty dir disables the cache.
+func WithCacheDir(dir string) HTTPOpt {
+	return func(h *HTTP) {
+		h.cacheDir = dir
+	}
+}
+
+// WithOffline only reads URLs from the cache, failing with ErrNotCached for anything not fetched before
+func WithOffline(offline bool) HTTPOpt {
+	return func(h *HTTP) {
+		h.offline = offline
+	}
+}
+
+// DefaultCacheDir returns the splinter directory of the user's cache directory
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "splinter", "http")
+}
+
+// IsURL reports whether name is an http or https URL
+func IsURL(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// ReadFile fetches a URL, or reads a local file through the wrapped FileIO
+func (h *HTTP) ReadFile(filename string) ([]byte, error) {
+	if !IsURL(filename) {
+		return h.FileIO.ReadFile(filename)
+	}
+
+	url, checksum, err := splitChecksum(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, cached := h.readCache(url)
+	if cached && checksum != "" && sum(entry.data) == checksum {
+		return entry.data, nil
+	}
+
+	if h.offline {
+		if !cached {
+			return nil, fmt.Errorf("%w: %s", ErrNotCached, url)
+		}
+		return entry.data, verify(url, entry.data, checksum)
+	}
+
+	data, etag, err := h.fetch(url, entry, cached)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(url, data, checksum); err != nil {
+		return nil, err
+	}
+
+	if err := h.writeCache(url, cacheEntry{URL: url, ETag: etag, data: data}); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Open reads a URL whole, as it may have to be checked against its checksum, or opens a local file through the
+// wrapped FileIO
+func (h *HTTP) Open(name string) (io.ReadCloser, error) {
+	if !IsURL(name) {
+		return Open(h.FileIO, name)
+	}
+
+	b, err := h.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// Stat describes a URL as a read-only file without fetching it, or stats a local file through the wrapped FileIO
+func (h *HTTP) Stat(name string) (fs.FileInfo, error) {
+	if !IsURL(name) {
+		return h.FileIO.Stat(name)
+	}
+	url, _, _ := strings.Cut(name, "#")
+	return urlInfo{name: path.Base(url)}, nil
+}
+
+// ReadDir reads a local directory through the wrapped FileIO. URLs cannot be listed.
+func (h *HTTP) ReadDir(name string) ([]fs.DirEntry, error) {
+	if IsURL(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	return h.FileIO.ReadDir(name)
+}
+
+// Create creates a local file through the wrapped FileIO. URLs are read-only.
+func (h *HTTP) Create(name string) (io.WriteCloser, error) {
+	if IsURL(name) {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: ErrReadOnly}
+	}
+	return h.FileIO.Create(name)
+}
+
+// WriteFile writes a local file through the wrapped FileIO. URLs are read-only.
+func (h *HTTP) WriteFile(filename string, data []byte, perm fs.FileMode) error {
+	if IsURL(filename) {
+		return &fs.PathError{Op: "write", Path: filename, Err: ErrReadOnly}
+	}
+	return h.FileIO.WriteFile(filename, data, perm)
+}
+
+// Remove removes a local file through the wrapped FileIO. URLs are read-only.
+func (h *HTTP) Remove(name string) error {
+	if IsURL(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+	}
+	return Remove(h.FileIO, name)
+}
+
+// fetch requests a URL, sending the ETag of the cached copy so an unchanged file is not downloaded again
+func (h *HTTP) fetch(url string, entry cacheEntry, cached bool) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrFetch, err)
+	}
+	if cached && entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrFetch, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached {
+		return entry.data, entry.ETag, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%w: %s: %s", ErrFetch, url, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s: %w", ErrFetch, url, err)
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// cacheEntry is the metadata kept next to a cached file
+type cacheEntry struct {
+	URL  string `json:"url"`
+	ETag string `json:"etag,omitempty"`
+	data []byte
+}
+
+// cachePath returns the path of the cached contents of a URL. Its metadata is kept in the same path with a .json
+// extension.
+func (h *HTTP) cachePath(url string) string {
+	return filepath.Join(h.cacheDir, sum([]byte(url)))
+}
+
+func (h *HTTP) readCache(url string) (cacheEntry, bool) {
+	if h.cacheDir == "" {
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	b, err := os.ReadFile(h.cachePath(url) + ".json")
+	if err != nil || json.Unmarshal(b, &entry) != nil || entry.URL != url {
+		return cacheEntry{}, false
+	}
+	if entry.data, err = os.ReadFile(h.cachePath(url)); err != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// writeCache stores a fetched file, writing through temporary files so concurrent readers never see partial contents
+func (h *HTTP) writeCache(url string, entry cacheEntry) error {
+	if h.cacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(h.cacheDir, 0o755); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	p := h.cachePath(url)
+	if err := writeAtomic(p, entry.data); err != nil {
+		return err
+	}
+	return writeAtomic(p+".json", meta)
+}
+
+func writeAtomic(name string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// splitChecksum splits a #sha256=<hex> fragment off a URL. Other fragments are dropped, as they are never sent.
+func splitChecksum(name string) (string, string, error) {
+	url, fragment, ok := strings.Cut(name, "#")
+	if !ok {
+		return url, "", nil
+	}
+
+	checksum, ok := strings.CutPrefix(fragment, checksumPrefix)
+	if !ok {
+		return url, "", nil
+	}
+	checksum = strings.ToLower(checksum)
+	if b, err := hex.DecodeString(checksum); err != nil || len(b) != sha256.Size {
+		return "", "", fmt.Errorf("%w: %s", ErrInvalidChecksum, name)
+	}
+	return url, checksum, nil
+}
+
+func verify(url string, data []byte, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	if got := sum(data); got != checksum {
+		return fmt.Errorf("%w: %s has sha256 %s, want %s", ErrChecksumMismatch, url, got, checksum)
+	}
+	return nil
+}
+
+func sum(data []byte) string {
+	s := sha256.Sum256(data)
+	return hex.EncodeToString(s[:])
+}
+
+// urlInfo describes a URL as a read-only file
+type urlInfo struct {
+	name string
+}
+
+func (i urlInfo) Name() string       { return i.name }
+func (i urlInfo) Size() int64        { return 0 }
+func (i urlInfo) Mode() fs.FileMode  { return 0o444 }
+func (i urlInfo) ModTime() time.Time { return time.Time{} }
+func (i urlInfo) IsDir() bool        { return false }
+func (i urlInfo) Sys() any           { return nil }
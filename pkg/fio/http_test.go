@@ -0,0 +1,157 @@
+package fio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const manifest = "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: operator\n"
+
+// newServer serves manifest at /install.yaml with an ETag, counting requests and full responses
+func newServer(t *testing.T) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+	var requests, downloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/install.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		w.Write([]byte(manifest))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests, &downloads
+}
+
+func TestHTTP_ReadFile(t *testing.T) {
+	srv, requests, downloads := newServer(t)
+	url := srv.URL + "/install.yaml"
+	checksum := sha256.Sum256([]byte(manifest))
+	pinned := url + "#sha256=" + hex.EncodeToString(checksum[:])
+
+	local := NewMemory()
+	local.AddFile("local.yaml", []byte("local"))
+	cache := t.TempDir()
+	h := NewHTTP(local, WithCacheDir(cache))
+
+	b, err := h.ReadFile(url)
+	if err != nil || string(b) != manifest {
+		t.Fatalf("ReadFile() = %q, %v, want %q", b, err, manifest)
+	}
+
+	// the cached copy is revalidated with its etag
+	if b, err = h.ReadFile(url); err != nil || string(b) != manifest {
+		t.Fatalf("ReadFile() = %q, %v, want %q", b, err, manifest)
+	}
+	if requests.Load() != 2 || downloads.Load() != 1 {
+		t.Errorf("requests = %d, downloads = %d, want 2 and 1", requests.Load(), downloads.Load())
+	}
+
+	// a pinned url matching the cache is not requested
+	if b, err = h.ReadFile(pinned); err != nil || string(b) != manifest {
+		t.Fatalf("ReadFile(pinned) = %q, %v, want %q", b, err, manifest)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want 2", requests.Load())
+	}
+
+	if _, err := h.ReadFile(url + "#sha256=" + hex.EncodeToString(make([]byte, sha256.Size))); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("ReadFile() with the wrong checksum error = %v, want %v", err, ErrChecksumMismatch)
+	}
+	if _, err := h.ReadFile(url + "#sha256=abc"); !errors.Is(err, ErrInvalidChecksum) {
+		t.Errorf("ReadFile() with a short checksum error = %v, want %v", err, ErrInvalidChecksum)
+	}
+	if _, err := h.ReadFile(srv.URL + "/missing.yaml"); !errors.Is(err, ErrFetch) {
+		t.Errorf("ReadFile() of a missing url error = %v, want %v", err, ErrFetch)
+	}
+
+	if b, err = h.ReadFile("local.yaml"); err != nil || string(b) != "local" {
+		t.Errorf("ReadFile(local.yaml) = %q, %v, want local", b, err)
+	}
+
+	offline := NewHTTP(local, WithCacheDir(cache), WithOffline(true))
+	srv.Close()
+	if b, err = offline.ReadFile(url); err != nil || string(b) != manifest {
+		t.Errorf("offline ReadFile() = %q, %v, want %q", b, err, manifest)
+	}
+	if _, err := offline.ReadFile(srv.URL + "/other.yaml"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("offline ReadFile() of an uncached url error = %v, want %v", err, ErrNotCached)
+	}
+}
+
+func TestHTTP_timeout(t *testing.T) {
+	stalled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(stalled) })
+
+	h := NewHTTP(NewMemory(), WithCacheDir(""), WithTimeout(50*time.Millisecond))
+	if _, err := h.ReadFile(srv.URL + "/install.yaml"); !errors.Is(err, ErrFetch) {
+		t.Errorf("ReadFile() error = %v, want %v", err, ErrFetch)
+	}
+	if timeout := NewHTTP(NewMemory()).client.Timeout; timeout != DefaultTimeout {
+		t.Errorf("default timeout = %v, want %v", timeout, DefaultTimeout)
+	}
+}
+
+func TestHTTP_withoutCache(t *testing.T) {
+	srv, requests, _ := newServer(t)
+	h := NewHTTP(NewMemory(), WithCacheDir(""))
+
+	for range 2 {
+		if _, err := h.ReadFile(srv.URL + "/install.yaml"); err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want 2", requests.Load())
+	}
+}
+
+func TestHTTP_Stat(t *testing.T) {
+	h := NewHTTP(NewMemory())
+
+	info, err := h.Stat("https://example.com/releases/install.yaml#sha256=abc")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Name() != "install.yaml" || info.IsDir() {
+		t.Errorf("Stat() = %s, dir %v, want install.yaml, not a dir", info.Name(), info.IsDir())
+	}
+
+	if _, err := h.Stat("missing.yaml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat(missing.yaml) error = %v, want %v", err, fs.ErrNotExist)
+	}
+	if err := h.WriteFile("https://example.com/out.yaml", nil, 0o644); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("WriteFile() error = %v, want %v", err, ErrReadOnly)
+	}
+}
+
+func TestIsURL(t *testing.T) {
+	for name, want := range map[string]bool{
+		"https://example.com/install.yaml": true,
+		"HTTP://example.com/install.yaml":  true,
+		"install.yaml":                     false,
+		"git:main:deploy/":                 false,
+		"ftp://example.com/install.yaml":   false,
+	} {
+		if got := IsURL(name); got != want {
+			t.Errorf("IsURL(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package fio
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+var _ FileIO = (*Memory)(nil)
+
+// Memory is a FileIO keeping files in memory, safe for concurrent use. Paths are resolved relative to the root of
+// the in-memory file system, so "./out" and "/out" both name "out". Writing a file requires its directory to exist,
+// as it does on disk.
+type Memory struct {
+	mu    sync.RWMutex
+	files fstest.MapFS
+}
+
+// NewMemory returns an empty in-memory FileIO
+func NewMemory() *Memory {
+	return &Memory{files: make(fstest.MapFS)}
+}
+
+// ReadFile reads the named file
+func (m *Memory) ReadFile(filename string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.ReadFile(fsPath(filename))
+}
+
+// Stat returns the fs.FileInfo of the named file or directory
+func (m *Memory) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.Stat(fsPath(name))
+}
+
+// ReadDir reads the named directory, returning its entries sorted by name
+func (m *Memory) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.ReadDir(fsPath(name))
+}
+
+// Create creates or truncates the named file. Its contents are visible once the returned writer is closed.
+func (m *Memory) Create(name string) (io.WriteCloser, error) {
+	if err := m.WriteFile(name, nil, 0o644); err != nil {
+		return nil, err
+	}
+	return &memoryFile{m: m, name: name}, nil
+}
+
+// MkdirAll creates the named directory along with any missing parents
+func (m *Memory) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for dir := fsPath(name); dir != "."; dir = path.Dir(dir) {
+		if f, ok := m.files[dir]; ok {
+			if !f.Mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+			}
+			continue
+		}
+		m.files[dir] = &fstest.MapFile{Mode: fs.ModeDir | perm.Perm(), ModTime: time.Now()}
+	}
+	return nil
+}
+
+// WriteFile writes data to the named file, creating it if necessary
+func (m *Memory) WriteFile(filename string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name := fsPath(filename)
+	if dir := path.Dir(name); dir != "." {
+		if info, err := m.files.Stat(dir); err != nil || !info.IsDir() {
+			return &fs.PathError{Op: "write", Path: filename, Err: fs.ErrNotExist}
+		}
+	}
+	if f, ok := m.files[name]; ok && f.Mode.IsDir() {
+		return &fs.PathError{Op: "write", Path: filename, Err: fs.ErrInvalid}
+	}
+
+	m.files[name] = &fstest.MapFile{Data: slices.Clone(data), Mode: perm.Perm(), ModTime: time.Now()}
+	return nil
+}
+
+// Remove removes the named file or empty directory
+func (m *Memory) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = fsPath(name)
+	f, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if f.Mode.IsDir() {
+		for other := range m.files {
+			if strings.HasPrefix(other, name+"/") {
+				return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+			}
+		}
+	}
+
+	delete(m.files, name)
+	return nil
+}
+
+// AddFile writes data to the named file, creating its directories. It is meant for seeding input.
+func (m *Memory) AddFile(name string, data []byte) {
+	if dir := path.Dir(fsPath(name)); dir != "." {
+		_ = m.MkdirAll(dir, 0o755)
+	}
+	_ = m.WriteFile(name, data, 0o644)
+}
+
+// Files returns the names of every file, excluding directories, in sorted order
+func (m *Memory) Files() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.files))
+	for name, f := range m.files {
+		if !f.Mode.IsDir() {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// FilesIn returns the names of the files under dir, relative to it, in sorted order
+func (m *Memory) FilesIn(dir string) []string {
+	prefix := fsPath(dir) + "/"
+	names := make([]string, 0)
+	for _, name := range m.Files() {
+		if prefix == "./" {
+			names = append(names, name)
+		} else if rel, ok := strings.CutPrefix(name, prefix); ok {
+			names = append(names, rel)
+		}
+	}
+	return names
+}
+
+// Contents returns the contents of the named file and whether it exists
+func (m *Memory) Contents(name string) (string, bool) {
+	b, err := m.ReadFile(name)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// FS returns a snapshot of the files as an fs.FS
+func (m *Memory) FS() fs.FS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := make(fstest.MapFS, len(m.files))
+	for name, f := range m.files {
+		c := *f
+		c.Data = slices.Clone(f.Data)
+		snapshot[name] = &c
+	}
+	return snapshot
+}
+
+// memoryFile buffers writes to a file of a Memory until it is closed
+type memoryFile struct {
+	m    *Memory
+	name string
+	buf  bytes.Buffer
+}
+
+func (f *memoryFile) Write(p []byte) (int, error) {
+	return f.buf.Write(p)
+}
+
+func (f *memoryFile) Close() error {
+	return f.m.WriteFile(f.name, f.buf.Bytes(), 0o644)
+}
@@ -0,0 +1,104 @@
+package fio
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+
+	if err := m.WriteFile("out/a.yaml", []byte("a"), 0o644); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("WriteFile() without a directory error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	if err := m.MkdirAll("./out/crds", 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := m.WriteFile("/out/a.yaml", []byte("a"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	w, err := m.Create("out/crds/b.yaml")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := w.Write([]byte("b")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if got, want := m.Files(), []string{"out/a.yaml", "out/crds/b.yaml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Files() = %v, want %v", got, want)
+	}
+	if got, want := m.FilesIn("out/"), []string{"a.yaml", "crds/b.yaml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilesIn() = %v, want %v", got, want)
+	}
+	if got, ok := m.Contents("out/crds/b.yaml"); !ok || got != "b" {
+		t.Errorf("Contents() = %q, %v, want %q, true", got, ok, "b")
+	}
+
+	info, err := m.Stat("out")
+	if err != nil || !info.IsDir() {
+		t.Errorf("Stat() = %v, %v, want a directory", info, err)
+	}
+	if _, err := m.Stat("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat() error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	entries, err := m.ReadDir("out")
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"a.yaml", "crds"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ReadDir() = %v, want %v", names, want)
+	}
+
+	if err := fstest.TestFS(m.FS(), "out/a.yaml", "out/crds/b.yaml"); err != nil {
+		t.Errorf("FS() %v", err)
+	}
+
+	if err := m.Remove("out/crds"); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Remove() of a directory with files error = %v, want %v", err, fs.ErrExist)
+	}
+	if err := Remove(m, "out/crds/b.yaml"); err != nil {
+		t.Errorf("Remove() error = %v", err)
+	}
+	if err := m.Remove("out/crds/b.yaml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Remove() of a removed file error = %v, want %v", err, fs.ErrNotExist)
+	}
+	if got, want := m.Files(), []string{"out/a.yaml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Files() after Remove() = %v, want %v", got, want)
+	}
+}
+
+func TestMemory_concurrent(t *testing.T) {
+	m := NewMemory()
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := string(rune('a'+i%26)) + ".yaml"
+			m.AddFile("out/"+name, []byte(name))
+			_, _ = m.ReadFile("out/" + name)
+			_ = m.Files()
+		}()
+	}
+	wg.Wait()
+
+	if got := len(m.Files()); got != 26 {
+		t.Errorf("Files() len = %d, want 26", got)
+	}
+}
@@ -0,0 +1,313 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	modeTree      = 0o40000
+	modeSymlink   = 0o120000
+	modeSubmodule = 0o160000
+)
+
+// treeEntry is an entry of a tree object
+type treeEntry struct {
+	name string
+	mode uint32
+	hash Hash
+}
+
+// FS is a read-only fs.FS over the tree of a commit
+type FS struct {
+	repo *Repository
+	root Hash
+	time time.Time
+}
+
+var (
+	_ fs.ReadFileFS = (*FS)(nil)
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+)
+
+// FS returns the tree of the commit a revision names as an fs.FS
+func (r *Repository) FS(rev string) (*FS, error) {
+	commit, err := r.Resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := r.tree(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := r.readObject(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FS{repo: r, root: root, time: commitTime(o.data)}, nil
+}
+
+// commitTime returns the committer time of a commit, used as the modification time of every file
+func commitTime(data []byte) time.Time {
+	committer, _ := header(data, "committer")
+	fields := strings.Fields(committer)
+	if len(fields) < 2 {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// Open opens the named file or directory
+func (f *FS) Open(name string) (fs.File, error) {
+	e, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.mode == modeTree {
+		entries, err := f.readDir(e)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &dir{info: f.info(e), entries: entries}, nil
+	}
+
+	data, err := f.readBlob(e)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{info: f.info(e), Reader: bytes.NewReader(data)}, nil
+}
+
+// ReadFile reads the named file
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	e, err := f.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if e.mode == modeTree {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+
+	data, err := f.readBlob(e)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// ReadDir reads the named directory, returning its entries sorted by name
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if e.mode != modeTree {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := f.readDir(e)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return entries, nil
+}
+
+// Stat returns the fs.FileInfo of the named file or directory
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	e, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return f.info(e), nil
+}
+
+// lookup walks the trees from the root to the named entry
+func (f *FS) lookup(op, name string) (treeEntry, error) {
+	if !fs.ValidPath(name) {
+		return treeEntry{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	e := treeEntry{name: ".", mode: modeTree, hash: f.root}
+	if name == "." {
+		return e, nil
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if e.mode != modeTree {
+			return treeEntry{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+
+		entries, err := f.repo.readTree(e.hash)
+		if err != nil {
+			return treeEntry{}, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+
+		i := slices.IndexFunc(entries, func(e treeEntry) bool { return e.name == part })
+		if i < 0 || entries[i].mode == modeSubmodule {
+			return treeEntry{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		e = entries[i]
+	}
+
+	return e, nil
+}
+
+func (f *FS) readDir(e treeEntry) ([]fs.DirEntry, error) {
+	entries, err := f.repo.readTree(e.hash)
+	if err != nil {
+		return nil, err
+	}
+
+	dirEntries := make([]fs.DirEntry, 0, len(entries))
+	for _, child := range entries {
+		if child.mode == modeSubmodule {
+			continue
+		}
+		dirEntries = append(dirEntries, fs.FileInfoToDirEntry(f.info(child)))
+	}
+	slices.SortFunc(dirEntries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return dirEntries, nil
+}
+
+func (f *FS) readBlob(e treeEntry) ([]byte, error) {
+	o, err := f.repo.readObject(e.hash)
+	if err != nil {
+		return nil, err
+	}
+	if o.kind != objectBlob {
+		return nil, fmt.Errorf("%w: %s is a %s, not a blob", ErrUnexpectedType, e.hash, o.kind)
+	}
+	return o.data, nil
+}
+
+func (f *FS) info(e treeEntry) fileInfo {
+	info := fileInfo{name: path.Base(e.name), modTime: f.time}
+	switch {
+	case e.mode == modeTree:
+		info.mode = fs.ModeDir | 0o755
+	case e.mode == modeSymlink:
+		info.mode = fs.ModeSymlink | 0o777
+	case e.mode&0o111 != 0:
+		info.mode = 0o755
+	default:
+		info.mode = 0o644
+	}
+
+	if e.mode != modeTree {
+		info.repo, info.hash = f.repo, e.hash
+	}
+	return info
+}
+
+// readTree parses a tree object
+func (r *Repository) readTree(h Hash) ([]treeEntry, error) {
+	o, err := r.readObject(h)
+	if err != nil {
+		return nil, err
+	}
+	if o.kind != objectTree {
+		return nil, fmt.Errorf("%w: %s is a %s, not a tree", ErrUnexpectedType, h, o.kind)
+	}
+
+	entries := make([]treeEntry, 0)
+	data := o.data
+	for len(data) > 0 {
+		header, rest, ok := bytes.Cut(data, []byte{0})
+		if !ok || len(rest) < 20 {
+			return nil, fmt.Errorf("%w: tree %s", ErrCorruptObject, h)
+		}
+		mode, name, ok := strings.Cut(string(header), " ")
+		if !ok {
+			return nil, fmt.Errorf("%w: tree %s", ErrCorruptObject, h)
+		}
+		m, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: tree %s: %w", ErrCorruptObject, h, err)
+		}
+
+		e := treeEntry{name: name, mode: uint32(m)}
+		copy(e.hash[:], rest[:20])
+		entries = append(entries, e)
+		data = rest[20:]
+	}
+
+	return entries, nil
+}
+
+// fileInfo describes a tree entry. The size of a file is read from its blob when asked for.
+type fileInfo struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+	repo    *Repository
+	hash    Hash
+}
+
+func (i fileInfo) Name() string { return i.name }
+
+func (i fileInfo) Size() int64 {
+	if i.repo == nil {
+		return 0
+	}
+	o, err := i.repo.readObject(i.hash)
+	if err != nil {
+		return 0
+	}
+	return int64(len(o.data))
+}
+
+func (i fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i fileInfo) ModTime() time.Time { return i.modTime }
+func (i fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i fileInfo) Sys() any           { return nil }
+
+type file struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+type dir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,158 @@
+package git
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// newRepository creates a repository with three commits to deploy/service.yaml, returning it with the commit ids
+// from oldest to newest
+func newRepository(t *testing.T, gc bool) (string, []string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=splinter", "GIT_AUTHOR_EMAIL=splinter@example.com",
+			"GIT_COMMITTER_NAME=splinter", "GIT_COMMITTER_EMAIL=splinter@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	run("init", "-q", "-b", "main")
+	commits := make([]string, 0)
+	for i, replicas := range []string{"1", "2", "3"} {
+		if err := os.MkdirAll(filepath.Join(dir, "deploy", "crds"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		// a large file that changes a little each commit so gc stores it as a delta
+		content := "kind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: " + replicas + "\n" + strings.Repeat("# padding\n", 200)
+		if err := os.WriteFile(filepath.Join(dir, "deploy", "deployment.yaml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if err := os.WriteFile(filepath.Join(dir, "deploy", "crds", "crd.yaml"), []byte("kind: CustomResourceDefinition\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		run("add", "-A")
+		run("commit", "-q", "-m", "replicas "+replicas)
+		commits = append(commits, run("rev-parse", "HEAD"))
+	}
+	run("tag", "-a", "v1", "-m", "v1", commits[0])
+	run("branch", "old", commits[1])
+
+	if gc {
+		run("gc", "-q", "--aggressive")
+	}
+	return dir, commits
+}
+
+func TestRepository_Resolve(t *testing.T) {
+	for _, gc := range []bool{false, true} {
+		name := "loose"
+		if gc {
+			name = "packed"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			dir, commits := newRepository(t, gc)
+			r, err := Open(filepath.Join(dir, "deploy"))
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+
+			tests := map[string]string{
+				"HEAD":           commits[2],
+				"main":           commits[2],
+				"refs/heads/old": commits[1],
+				"old":            commits[1],
+				"v1":             commits[0],
+				"HEAD~2":         commits[0],
+				"main^":          commits[1],
+				"main~1^":        commits[0],
+				commits[1]:       commits[1],
+				commits[1][:8]:   commits[1],
+			}
+			for rev, want := range tests {
+				got, err := r.Resolve(rev)
+				if err != nil {
+					t.Errorf("Resolve(%q) error = %v", rev, err)
+					continue
+				}
+				if got.String() != want {
+					t.Errorf("Resolve(%q) = %s, want %s", rev, got, want)
+				}
+			}
+
+			for _, rev := range []string{"missing", "HEAD~5"} {
+				if _, err := r.Resolve(rev); !errors.Is(err, ErrUnknownRev) {
+					t.Errorf("Resolve(%q) error = %v, want %v", rev, err, ErrUnknownRev)
+				}
+			}
+		})
+	}
+}
+
+func TestRepository_FS(t *testing.T) {
+	for _, gc := range []bool{false, true} {
+		name := "loose"
+		if gc {
+			name = "packed"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			dir, _ := newRepository(t, gc)
+			r, err := Open(dir)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+
+			for rev, replicas := range map[string]string{"main": "3", "old": "2", "v1": "1"} {
+				fsys, err := r.FS(rev)
+				if err != nil {
+					t.Fatalf("FS(%q) error = %v", rev, err)
+				}
+
+				b, err := fs.ReadFile(fsys, "deploy/deployment.yaml")
+				if err != nil {
+					t.Fatalf("ReadFile() error = %v", err)
+				}
+				if !strings.Contains(string(b), "replicas: "+replicas+"\n") {
+					t.Errorf("%s deploy/deployment.yaml does not have %s replicas", rev, replicas)
+				}
+
+				if err := fstest.TestFS(fsys, "deploy/deployment.yaml", "deploy/crds/crd.yaml"); err != nil {
+					t.Errorf("TestFS(%q) %v", rev, err)
+				}
+			}
+
+			fsys, _ := r.FS("main")
+			if _, err := fsys.Stat("deploy/missing.yaml"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Stat() error = %v, want %v", err, fs.ErrNotExist)
+			}
+		})
+	}
+}
+
+func TestOpen_notRepository(t *testing.T) {
+	if _, err := Open(t.TempDir()); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Open() error = %v, want %v", err, ErrNotRepository)
+	}
+}
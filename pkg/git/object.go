@@ -0,0 +1,373 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	objectCommit   = "commit"
+	objectTree     = "tree"
+	objectBlob     = "blob"
+	objectTag      = "tag"
+	packOfsDelta   = 6
+	packRefDelta   = 7
+	packIndexMagic = "\xfftOc"
+	maxDeltaChain  = 1000
+)
+
+var (
+	ErrObjectNotFound  = errors.New("object not found")
+	ErrAmbiguousObject = errors.New("short object id is ambiguous")
+	ErrCorruptObject   = errors.New("corrupt object")
+)
+
+// packTypes maps the object types of a pack to their names
+var packTypes = map[byte]string{1: objectCommit, 2: objectTree, 3: objectBlob, 4: objectTag}
+
+// Hash is a SHA-1 object id
+type Hash [20]byte
+
+// String returns the hash as lowercase hex
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// ParseHash parses a full 40 character hex object id
+func ParseHash(s string) (Hash, bool) {
+	var h Hash
+	if len(s) != 40 {
+		return h, false
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, false
+	}
+	return h, true
+}
+
+// object is a decompressed git object
+type object struct {
+	kind string
+	data []byte
+}
+
+// packIndex is a version 2 pack index along with the path of its pack
+type packIndex struct {
+	pack    string
+	fanout  [256]uint32
+	hashes  []byte
+	offsets []byte
+	large   []byte
+}
+
+// readObject reads an object from the loose objects or the packs
+func (r *Repository) readObject(h Hash) (object, error) {
+	if o, ok := r.cached(h); ok {
+		return o, nil
+	}
+
+	o, err := r.readLooseObject(h)
+	if errors.Is(err, os.ErrNotExist) {
+		o, err = r.readPackedObject(h)
+	}
+	if err != nil {
+		return object{}, err
+	}
+
+	r.cache(h, o)
+	return o, nil
+}
+
+func (r *Repository) readLooseObject(h Hash) (object, error) {
+	s := h.String()
+	f, err := os.Open(filepath.Join(r.objectsDir(), s[:2], s[2:]))
+	if err != nil {
+		return object{}, err
+	}
+	defer f.Close()
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return object{}, fmt.Errorf("%w %s: %w", ErrCorruptObject, s, err)
+	}
+	defer zr.Close()
+
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		return object{}, fmt.Errorf("%w %s: %w", ErrCorruptObject, s, err)
+	}
+
+	header, data, ok := bytes.Cut(b, []byte{0})
+	kind, size, ok2 := strings.Cut(string(header), " ")
+	if !ok || !ok2 || size != strconv.Itoa(len(data)) {
+		return object{}, fmt.Errorf("%w %s: bad header", ErrCorruptObject, s)
+	}
+
+	return object{kind: kind, data: data}, nil
+}
+
+func (r *Repository) readPackedObject(h Hash) (object, error) {
+	indexes, err := r.packIndexes()
+	if err != nil {
+		return object{}, err
+	}
+
+	for _, idx := range indexes {
+		if offset, ok := idx.find(h); ok {
+			return r.readPackObject(idx.pack, offset, 0)
+		}
+	}
+
+	return object{}, fmt.Errorf("%w: %s", ErrObjectNotFound, h)
+}
+
+// readPackObject reads the object at offset of a pack, resolving deltas against their base
+func (r *Repository) readPackObject(pack string, offset int64, depth int) (object, error) {
+	if depth > maxDeltaChain {
+		return object{}, fmt.Errorf("%w: delta chain too long in %s", ErrCorruptObject, pack)
+	}
+
+	f, err := os.Open(pack)
+	if err != nil {
+		return object{}, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+	c, err := br.ReadByte()
+	if err != nil {
+		return object{}, err
+	}
+	kind := (c >> 4) & 7
+	for c&0x80 != 0 {
+		if c, err = br.ReadByte(); err != nil {
+			return object{}, err
+		}
+	}
+
+	var base object
+	switch kind {
+	case packOfsDelta:
+		c, err := br.ReadByte()
+		if err != nil {
+			return object{}, err
+		}
+		rel := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = br.ReadByte(); err != nil {
+				return object{}, err
+			}
+			rel = ((rel + 1) << 7) | int64(c&0x7f)
+		}
+		if base, err = r.readPackObject(pack, offset-rel, depth+1); err != nil {
+			return object{}, err
+		}
+	case packRefDelta:
+		var h Hash
+		if _, err := io.ReadFull(br, h[:]); err != nil {
+			return object{}, err
+		}
+		if base, err = r.readObject(h); err != nil {
+			return object{}, err
+		}
+	}
+
+	zr, err := zlib.NewReader(br)
+	if err != nil {
+		return object{}, fmt.Errorf("%w in %s at %d: %w", ErrCorruptObject, pack, offset, err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return object{}, fmt.Errorf("%w in %s at %d: %w", ErrCorruptObject, pack, offset, err)
+	}
+
+	if kind == packOfsDelta || kind == packRefDelta {
+		patched, err := applyDelta(base.data, data)
+		if err != nil {
+			return object{}, fmt.Errorf("%w in %s at %d: %w", ErrCorruptObject, pack, offset, err)
+		}
+		return object{kind: base.kind, data: patched}, nil
+	}
+
+	name, ok := packTypes[kind]
+	if !ok {
+		return object{}, fmt.Errorf("%w: unknown type %d in %s at %d", ErrCorruptObject, kind, pack, offset)
+	}
+	return object{kind: name, data: data}, nil
+}
+
+// applyDelta rebuilds an object from its base and a delta of copy and insert instructions
+func applyDelta(base, delta []byte) ([]byte, error) {
+	srcSize, n := binary.Uvarint(delta)
+	if n <= 0 || srcSize != uint64(len(base)) {
+		return nil, errors.New("delta base size mismatch")
+	}
+	delta = delta[n:]
+	dstSize, n := binary.Uvarint(delta)
+	if n <= 0 {
+		return nil, errors.New("bad delta size")
+	}
+	delta = delta[n:]
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 == 0 {
+			if op == 0 || int(op) > len(delta) {
+				return nil, errors.New("bad delta insert")
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+			continue
+		}
+
+		var offset, size uint64
+		for i := range 7 {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, errors.New("truncated delta copy")
+			}
+			if i < 4 {
+				offset |= uint64(delta[0]) << (8 * i)
+			} else {
+				size |= uint64(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > uint64(len(base)) {
+			return nil, errors.New("delta copy out of range")
+		}
+		out = append(out, base[offset:offset+size]...)
+	}
+
+	if uint64(len(out)) != dstSize {
+		return nil, errors.New("delta result size mismatch")
+	}
+	return out, nil
+}
+
+// packIndexes loads the indexes of every pack once
+func (r *Repository) packIndexes() ([]*packIndex, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexes != nil {
+		return r.indexes, nil
+	}
+
+	names, err := filepath.Glob(filepath.Join(r.objectsDir(), "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]*packIndex, 0, len(names))
+	for _, name := range names {
+		idx, err := readPackIndex(name)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, idx)
+	}
+
+	r.indexes = indexes
+	return indexes, nil
+}
+
+func readPackIndex(name string) (*packIndex, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 8+256*4 || string(b[:4]) != packIndexMagic || binary.BigEndian.Uint32(b[4:8]) != 2 {
+		return nil, fmt.Errorf("%w: unsupported pack index %s", ErrCorruptObject, name)
+	}
+
+	idx := &packIndex{pack: strings.TrimSuffix(name, ".idx") + ".pack"}
+	for i := range idx.fanout {
+		idx.fanout[i] = binary.BigEndian.Uint32(b[8+i*4:])
+	}
+
+	n := int(idx.fanout[255])
+	start := 8 + 256*4
+	end := start + n*20 + n*4 + n*4
+	if len(b) < end {
+		return nil, fmt.Errorf("%w: truncated pack index %s", ErrCorruptObject, name)
+	}
+	idx.hashes = b[start : start+n*20]
+	idx.offsets = b[start+n*24 : end]
+	idx.large = b[end:]
+
+	return idx, nil
+}
+
+// find returns the offset of an object in the pack
+func (idx *packIndex) find(h Hash) (int64, bool) {
+	lo, hi := idx.bounds(h[0])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(idx.hash(lo+i), h[:]) >= 0
+	})
+	if i >= hi || !bytes.Equal(idx.hash(i), h[:]) {
+		return 0, false
+	}
+	return idx.offset(i), true
+}
+
+// withPrefix returns every object id of the pack starting with the hex prefix
+func (idx *packIndex) withPrefix(prefix string) []Hash {
+	first, err := strconv.ParseUint(prefix[:2], 16, 8)
+	if err != nil {
+		return nil
+	}
+
+	hashes := make([]Hash, 0)
+	lo, hi := idx.bounds(byte(first))
+	for i := lo; i < hi; i++ {
+		var h Hash
+		copy(h[:], idx.hash(i))
+		if strings.HasPrefix(h.String(), prefix) {
+			hashes = append(hashes, h)
+		}
+	}
+	return hashes
+}
+
+func (idx *packIndex) bounds(first byte) (int, int) {
+	lo := 0
+	if first > 0 {
+		lo = int(idx.fanout[first-1])
+	}
+	return lo, int(idx.fanout[first])
+}
+
+func (idx *packIndex) hash(i int) []byte {
+	return idx.hashes[i*20 : (i+1)*20]
+}
+
+func (idx *packIndex) offset(i int) int64 {
+	offset := binary.BigEndian.Uint32(idx.offsets[i*4:])
+	if offset&0x80000000 == 0 {
+		return int64(offset)
+	}
+	large := int(offset&0x7fffffff) * 8
+	return int64(binary.BigEndian.Uint64(idx.large[large:]))
+}
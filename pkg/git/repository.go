@@ -0,0 +1,391 @@
+// Package git reads commits, trees and files from the object store of a local repository without a checkout
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrNotRepository  = errors.New("not a git repository")
+	ErrUnknownRev     = errors.New("unknown revision")
+	ErrUnexpectedType = errors.New("unexpected object type")
+)
+
+// Repository is a local git repository
+type Repository struct {
+	// gitDir holds HEAD and the refs of the worktree, commonDir the objects and shared refs
+	gitDir    string
+	commonDir string
+	worktree  string
+
+	mu      sync.Mutex
+	indexes []*packIndex
+	objects map[Hash]object
+}
+
+// Open opens the repository containing dir, looking for a .git directory or file in dir and its parents
+func Open(dir string) (*Repository, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for d := abs; ; d = filepath.Dir(d) {
+		gitDir, err := findGitDir(d)
+		if err != nil {
+			return nil, err
+		}
+		if gitDir != "" {
+			return openGitDir(gitDir, d)
+		}
+		if filepath.Dir(d) == d {
+			return nil, fmt.Errorf("%w: %s", ErrNotRepository, dir)
+		}
+	}
+}
+
+// findGitDir returns the git directory of a worktree root, following the gitdir pointer of a .git file
+func findGitDir(dir string) (string, error) {
+	dotGit := filepath.Join(dir, ".git")
+	info, err := os.Stat(dotGit)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	b, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotRepository, dotGit)
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	return target, nil
+}
+
+func openGitDir(gitDir, worktree string) (*Repository, error) {
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotRepository, gitDir)
+	}
+
+	commonDir := gitDir
+	if b, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(b))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	return &Repository{
+		gitDir:    gitDir,
+		commonDir: commonDir,
+		worktree:  worktree,
+		objects:   make(map[Hash]object),
+	}, nil
+}
+
+// Worktree returns the root of the repository's working tree
+func (r *Repository) Worktree() string {
+	return r.worktree
+}
+
+func (r *Repository) objectsDir() string {
+	return filepath.Join(r.commonDir, "objects")
+}
+
+func (r *Repository) cached(h Hash) (object, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.objects[h]
+	return o, ok
+}
+
+// cache keeps commits, trees and tags, which are read repeatedly when walking paths. Blobs are read once.
+func (r *Repository) cache(h Hash, o object) {
+	if o.kind == objectBlob {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.objects[h] = o
+}
+
+// Resolve returns the commit a revision names. A revision is a full or abbreviated object id, HEAD, a branch, tag or
+// remote-tracking ref, optionally followed by ~n and ^n suffixes.
+func (r *Repository) Resolve(rev string) (Hash, error) {
+	base, suffix := splitRevSuffix(rev)
+
+	h, err := r.resolveName(base)
+	if err != nil {
+		return Hash{}, err
+	}
+	if h, err = r.peelToCommit(h); err != nil {
+		return Hash{}, err
+	}
+
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+
+		digits := 0
+		for digits < len(suffix) && suffix[digits] >= '0' && suffix[digits] <= '9' {
+			digits++
+		}
+		n := 1
+		if digits > 0 {
+			n, _ = strconv.Atoi(suffix[:digits])
+		}
+		suffix = suffix[digits:]
+
+		if op == '~' {
+			for range n {
+				if h, err = r.parent(h, 1); err != nil {
+					return Hash{}, fmt.Errorf("%w: %s", err, rev)
+				}
+			}
+		} else if n > 0 {
+			if h, err = r.parent(h, n); err != nil {
+				return Hash{}, fmt.Errorf("%w: %s", err, rev)
+			}
+		}
+	}
+
+	return h, nil
+}
+
+// splitRevSuffix splits the ~ and ^ ancestry suffixes off a revision
+func splitRevSuffix(rev string) (string, string) {
+	if i := strings.IndexAny(rev, "~^"); i > 0 {
+		return rev[:i], rev[i:]
+	}
+	return rev, ""
+}
+
+// resolveName resolves a ref name or object id in the order git does
+func (r *Repository) resolveName(name string) (Hash, error) {
+	if h, ok := ParseHash(name); ok {
+		return h, nil
+	}
+
+	candidates := []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"}
+	for _, ref := range candidates {
+		h, ok, err := r.readRef(ref, 0)
+		if err != nil {
+			return Hash{}, err
+		}
+		if ok {
+			return h, nil
+		}
+	}
+
+	if len(name) >= 4 && isHex(name) {
+		return r.resolvePrefix(strings.ToLower(name))
+	}
+
+	return Hash{}, fmt.Errorf("%w: %s", ErrUnknownRev, name)
+}
+
+// readRef reads a loose or packed ref, following symbolic refs
+func (r *Repository) readRef(name string, depth int) (Hash, bool, error) {
+	if depth > 5 || strings.Contains(name, "..") {
+		return Hash{}, false, nil
+	}
+
+	dirs := []string{r.gitDir}
+	if r.commonDir != r.gitDir {
+		dirs = append(dirs, r.commonDir)
+	}
+
+	for _, dir := range dirs {
+		f := filepath.Join(dir, filepath.FromSlash(name))
+		if info, err := os.Stat(f); err != nil || info.IsDir() {
+			continue
+		}
+
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return Hash{}, false, err
+		}
+
+		value := strings.TrimSpace(string(b))
+		if target, ok := strings.CutPrefix(value, "ref:"); ok {
+			return r.readRef(strings.TrimSpace(target), depth+1)
+		}
+		if h, ok := ParseHash(value); ok {
+			return h, true, nil
+		}
+	}
+
+	return r.readPackedRef(name)
+}
+
+func (r *Repository) readPackedRef(name string) (Hash, bool, error) {
+	b, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Hash{}, false, nil
+	}
+	if err != nil {
+		return Hash{}, false, err
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		hash, ref, ok := strings.Cut(line, " ")
+		if !ok || ref != name {
+			continue
+		}
+		if h, ok := ParseHash(hash); ok {
+			return h, true, nil
+		}
+	}
+
+	return Hash{}, false, s.Err()
+}
+
+// resolvePrefix finds the single object whose id starts with an abbreviated hex id
+func (r *Repository) resolvePrefix(prefix string) (Hash, error) {
+	found := make(map[Hash]bool)
+
+	entries, _ := os.ReadDir(filepath.Join(r.objectsDir(), prefix[:2]))
+	for _, e := range entries {
+		if h, ok := ParseHash(prefix[:2] + e.Name()); ok && strings.HasPrefix(h.String(), prefix) {
+			found[h] = true
+		}
+	}
+
+	indexes, err := r.packIndexes()
+	if err != nil {
+		return Hash{}, err
+	}
+	for _, idx := range indexes {
+		for _, h := range idx.withPrefix(prefix) {
+			found[h] = true
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Hash{}, fmt.Errorf("%w: %s", ErrUnknownRev, prefix)
+	case 1:
+		for h := range found {
+			return h, nil
+		}
+	}
+	return Hash{}, fmt.Errorf("%w: %s", ErrAmbiguousObject, prefix)
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// peelToCommit follows annotated tags to the commit they point at
+func (r *Repository) peelToCommit(h Hash) (Hash, error) {
+	for range 10 {
+		o, err := r.readObject(h)
+		if err != nil {
+			return Hash{}, err
+		}
+
+		switch o.kind {
+		case objectCommit:
+			return h, nil
+		case objectTag:
+			target, ok := header(o.data, "object")
+			if !ok {
+				return Hash{}, fmt.Errorf("%w: tag %s", ErrCorruptObject, h)
+			}
+			if h, ok = ParseHash(target); !ok {
+				return Hash{}, fmt.Errorf("%w: tag %s", ErrCorruptObject, h)
+			}
+		default:
+			return Hash{}, fmt.Errorf("%w: %s is a %s, not a commit", ErrUnexpectedType, h, o.kind)
+		}
+	}
+	return Hash{}, fmt.Errorf("%w: tag chain too long at %s", ErrCorruptObject, h)
+}
+
+// parent returns the nth parent of a commit, counting from 1
+func (r *Repository) parent(h Hash, n int) (Hash, error) {
+	o, err := r.readObject(h)
+	if err != nil {
+		return Hash{}, err
+	}
+
+	parents := headers(o.data, "parent")
+	if n > len(parents) {
+		return Hash{}, fmt.Errorf("%w: %s has no parent %d", ErrUnknownRev, h, n)
+	}
+	p, ok := ParseHash(parents[n-1])
+	if !ok {
+		return Hash{}, fmt.Errorf("%w: commit %s", ErrCorruptObject, h)
+	}
+	return p, nil
+}
+
+// tree returns the root tree of a commit
+func (r *Repository) tree(commit Hash) (Hash, error) {
+	o, err := r.readObject(commit)
+	if err != nil {
+		return Hash{}, err
+	}
+
+	value, ok := header(o.data, "tree")
+	if !ok {
+		return Hash{}, fmt.Errorf("%w: commit %s", ErrCorruptObject, commit)
+	}
+	h, ok := ParseHash(value)
+	if !ok {
+		return Hash{}, fmt.Errorf("%w: commit %s", ErrCorruptObject, commit)
+	}
+	return h, nil
+}
+
+// header returns the first value of a header of a commit or tag
+func header(data []byte, name string) (string, bool) {
+	values := headers(data, name)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// headers returns every value of a header of a commit or tag, stopping at the message
+func headers(data []byte, name string) []string {
+	values := make([]string, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, name+" "); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
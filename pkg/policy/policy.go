@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrMissingName       = errors.New("policy has no name")
+	ErrMissingValidation = errors.New("policy has no validations")
+	ErrInvalidSeverity   = errors.New("policy severity must be error, warning or note")
+)
+
+// severities are the levels a SARIF result can have
+var severities = []string{"error", "warning", "note"}
+
+// File is a yaml file holding policies
+type File struct {
+	Policies []Policy `yaml:"policies"`
+}
+
+// Policy is a set of CEL validations evaluated against every resource it matches, in the style of a
+// ValidatingAdmissionPolicy. The resource is available to expressions as object. Severity is error, warning or note,
+// defaulting to error.
+type Policy struct {
+	Name        string       `yaml:"name"`
+	Severity    string       `yaml:"severity,omitempty"`
+	Match       Match        `yaml:"match,omitempty"`
+	Validations []Validation `yaml:"validations"`
+}
+
+// Match scopes a policy. Empty lists match everything.
+type Match struct {
+	Kinds             []string `yaml:"kinds,omitempty"`
+	Namespaces        []string `yaml:"namespaces,omitempty"`
+	ExcludeNamespaces []string `yaml:"excludeNamespaces,omitempty"`
+}
+
+// Validation is a CEL expression that must evaluate to true
+type Validation struct {
+	Expression string `yaml:"expression"`
+	Message    string `yaml:"message,omitempty"`
+}
+
+// Compiled is a policy whose expressions are ready to evaluate
+type Compiled struct {
+	Policy
+	programs []cel.Program
+}
+
+// Read decodes every policy of a policy file
+func Read(r io.Reader) ([]Policy, error) {
+	var f File
+	if err := yaml.NewDecoder(r).Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return f.Policies, nil
+}
+
+// Compile type checks every validation expression of the policy
+func Compile(p Policy) (*Compiled, error) {
+	if p.Name == "" {
+		return nil, ErrMissingName
+	}
+	if len(p.Validations) == 0 {
+		return nil, fmt.Errorf("%s: %w", p.Name, ErrMissingValidation)
+	}
+	if p.Severity != "" && !slices.Contains(severities, p.Severity) {
+		return nil, fmt.Errorf("%s: %w: %q", p.Name, ErrInvalidSeverity, p.Severity)
+	}
+
+	env, err := cel.NewEnv(cel.Variable("object", cel.DynType))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Compiled{Policy: p, programs: make([]cel.Program, 0, len(p.Validations))}
+	for _, v := range p.Validations {
+		ast, issues := env.Compile(v.Expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name, issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			return nil, fmt.Errorf("%s: expression %q must evaluate to a bool", p.Name, v.Expression)
+		}
+
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name, err)
+		}
+		c.programs = append(c.programs, program)
+	}
+
+	return c, nil
+}
+
+// Matches reports whether the policy applies to a resource of the given kind and namespace
+func (c *Compiled) Matches(kind, namespace string) bool {
+	if len(c.Match.Kinds) > 0 && !slices.Contains(c.Match.Kinds, kind) {
+		return false
+	}
+	if len(c.Match.Namespaces) > 0 && !slices.Contains(c.Match.Namespaces, namespace) {
+		return false
+	}
+	return !slices.Contains(c.Match.ExcludeNamespaces, namespace)
+}
+
+// Evaluate runs every validation against obj and returns the message of each one that fails
+func (c *Compiled) Evaluate(obj map[string]any) []string {
+	activation := map[string]any{"object": plain(obj)}
+
+	msgs := make([]string, 0)
+	for i, program := range c.programs {
+		v := c.Validations[i]
+		out, _, err := program.Eval(activation)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("evaluating %q: %v", v.Expression, err))
+			continue
+		}
+
+		if ok, isBool := out.Value().(bool); isBool && ok {
+			continue
+		}
+
+		msg := v.Message
+		if msg == "" {
+			msg = fmt.Sprintf("failed expression: %s", v.Expression)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// plain converts named map types produced by the yaml decoder into the generic types CEL understands
+func plain(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, v := range t {
+			m[k] = plain(v)
+		}
+		return m
+	case []any:
+		l := make([]any, len(t))
+		for i, v := range t {
+			l[i] = plain(v)
+		}
+		return l
+	case int:
+		return int64(t)
+	default:
+		return namedMap(v)
+	}
+}
+
+// namedMap converts maps of a named type with string keys, such as parser.Resource, into map[string]any
+func namedMap(v any) any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return v
+	}
+
+	m := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = plain(iter.Value().Interface())
+	}
+	return m
+}
@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type resource map[string]any
+
+func TestRead(t *testing.T) {
+	input := `policies:
+  - name: team-label
+    match:
+      kinds: [Deployment]
+      namespaces: [prod]
+    validations:
+      - expression: "'team' in object.metadata.labels"
+        message: needs a team label
+`
+	want := []Policy{
+		{
+			Name:        "team-label",
+			Match:       Match{Kinds: []string{"Deployment"}, Namespaces: []string{"prod"}},
+			Validations: []Validation{{Expression: "'team' in object.metadata.labels", Message: "needs a team label"}},
+		},
+	}
+
+	got, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			policy: Policy{Name: "p", Validations: []Validation{{Expression: "object.spec.replicas > 1"}}},
+		},
+		{
+			name:    "missing name",
+			policy:  Policy{Validations: []Validation{{Expression: "true"}}},
+			wantErr: true,
+		},
+		{
+			name:    "no validations",
+			policy:  Policy{Name: "p"},
+			wantErr: true,
+		},
+		{
+			name:    "syntax error",
+			policy:  Policy{Name: "p", Validations: []Validation{{Expression: "object.spec.(("}}},
+			wantErr: true,
+		},
+		{
+			name:   "severity",
+			policy: Policy{Name: "p", Severity: "note", Validations: []Validation{{Expression: "true"}}},
+		},
+		{
+			name:    "invalid severity",
+			policy:  Policy{Name: "p", Severity: "critical", Validations: []Validation{{Expression: "true"}}},
+			wantErr: true,
+		},
+		{
+			name:    "not a bool",
+			policy:  Policy{Name: "p", Validations: []Validation{{Expression: "1 + 1"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompiled_Evaluate(t *testing.T) {
+	c, err := Compile(Policy{
+		Name: "deployment",
+		Validations: []Validation{
+			{Expression: "has(object.metadata.labels) && 'team' in object.metadata.labels", Message: "needs a team label"},
+			{Expression: "object.spec.replicas >= 2"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		obj  map[string]any
+		want []string
+	}{
+		{
+			name: "passes",
+			obj: resource{
+				"metadata": resource{"labels": resource{"team": "web"}},
+				"spec":     resource{"replicas": 3},
+			},
+			want: []string{},
+		},
+		{
+			name: "fails both",
+			obj: resource{
+				"metadata": resource{"name": "web"},
+				"spec":     resource{"replicas": 1},
+			},
+			want: []string{"needs a team label", "failed expression: object.spec.replicas >= 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Evaluate(tt.obj); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompiled_Matches(t *testing.T) {
+	c := &Compiled{Policy: Policy{Match: Match{Kinds: []string{"Service"}, ExcludeNamespaces: []string{"kube-system"}}}}
+
+	if !c.Matches("Service", "default") {
+		t.Error("expected Service in default to match")
+	}
+	if c.Matches("Service", "kube-system") {
+		t.Error("expected excluded namespace not to match")
+	}
+	if c.Matches("Deployment", "default") {
+		t.Error("expected other kinds not to match")
+	}
+}
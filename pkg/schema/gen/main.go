@@ -0,0 +1,94 @@
+// Command gen compacts a kubernetes swagger.json into the schema bundle embedded by the schema package
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/kdwils/splinter/pkg/schema"
+)
+
+type groupVersionKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+type swagger struct {
+	Definitions map[string]json.RawMessage `json:"definitions"`
+}
+
+func main() {
+	output := flag.String("o", "", "path of the gzipped bundle to write")
+	flag.Parse()
+
+	if *output == "" || flag.NArg() != 1 {
+		log.Fatal("usage: gen -o schemas/v1.31.json.gz path/to/swagger.json")
+	}
+
+	b, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var s swagger
+	if err := json.Unmarshal(b, &s); err != nil {
+		log.Fatal(err)
+	}
+
+	bundle := schema.Bundle{
+		Definitions: make(map[string]*schema.Schema, len(s.Definitions)),
+		Kinds:       make(map[string]string),
+	}
+
+	for name, raw := range s.Definitions {
+		var def schema.Schema
+		if err := json.Unmarshal(raw, &def); err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+
+		// Quantities are declared as strings but accept plain numbers in manifests
+		if name == "io.k8s.apimachinery.pkg.api.resource.Quantity" {
+			def.Format = "quantity"
+		}
+		bundle.Definitions[name] = &def
+
+		var ext struct {
+			GVK []groupVersionKind `json:"x-kubernetes-group-version-kind"`
+		}
+		if err := json.Unmarshal(raw, &ext); err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+
+		// Only definitions with apiVersion and kind properties are served as top-level objects
+		if def.Properties["kind"] == nil || def.Properties["apiVersion"] == nil || len(ext.GVK) != 1 {
+			continue
+		}
+
+		gvk := ext.GVK[0]
+		apiVersion := gvk.Version
+		if gvk.Group != "" {
+			apiVersion = gvk.Group + "/" + gvk.Version
+		}
+		bundle.Kinds[apiVersion+"/"+gvk.Kind] = name
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewWriterLevel(f, gzip.BestCompression)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer gz.Close()
+
+	if err := json.NewEncoder(gz).Encode(bundle); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,8 @@
+package schema
+
+// The bundled schemas are generated from api/openapi-spec/swagger.json of each kubernetes release, for example
+// from the k8s.io/kubernetes module source. Set SWAGGER_DIR to a directory holding <version>/swagger.json.
+//go:generate go run ./gen -o schemas/v1.28.json.gz ${SWAGGER_DIR}/1.28/swagger.json
+//go:generate go run ./gen -o schemas/v1.29.json.gz ${SWAGGER_DIR}/1.29/swagger.json
+//go:generate go run ./gen -o schemas/v1.30.json.gz ${SWAGGER_DIR}/1.30/swagger.json
+//go:generate go run ./gen -o schemas/v1.31.json.gz ${SWAGGER_DIR}/1.31/swagger.json
@@ -0,0 +1,234 @@
+package schema
+
+import (
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed schemas/*.json.gz
+var bundled embed.FS
+
+const (
+	// DefaultVersion is the kubernetes version used when none is requested
+	DefaultVersion = "1.31"
+
+	definitionRefPrefix  = "#/definitions/"
+	objectMetaDefinition = "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"
+)
+
+var (
+	ErrUnknownVersion = errors.New("no bundled schemas for kubernetes version")
+	ErrInvalidCRD     = errors.New("invalid CustomResourceDefinition")
+)
+
+// Schema is the subset of an OpenAPI schema used to validate kubernetes resources
+type Schema struct {
+	Ref                   string             `json:"$ref,omitempty"`
+	Type                  string             `json:"type,omitempty"`
+	Format                string             `json:"format,omitempty"`
+	Properties            map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties  *Schema            `json:"additionalProperties,omitempty"`
+	Items                 *Schema            `json:"items,omitempty"`
+	Required              []string           `json:"required,omitempty"`
+	AllOf                 []*Schema          `json:"allOf,omitempty"`
+	AnyOf                 []*Schema          `json:"anyOf,omitempty"`
+	OneOf                 []*Schema          `json:"oneOf,omitempty"`
+	IntOrString           bool               `json:"x-kubernetes-int-or-string,omitempty"`
+	PreserveUnknownFields bool               `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+	EmbeddedResource      bool               `json:"x-kubernetes-embedded-resource,omitempty"`
+}
+
+// UnmarshalJSON accepts boolean schemas, which CRDs use for additionalProperties. Both are treated as an unconstrained schema.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	if trimmed := bytes.TrimSpace(b); bytes.Equal(trimmed, []byte("true")) || bytes.Equal(trimmed, []byte("false")) {
+		*s = Schema{}
+		return nil
+	}
+
+	type plain Schema
+	return json.Unmarshal(b, (*plain)(s))
+}
+
+// Bundle is the on-disk format of the bundled schemas
+type Bundle struct {
+	// Definitions are keyed by their OpenAPI definition name
+	Definitions map[string]*Schema `json:"definitions"`
+	// Kinds maps apiVersion/kind to a definition name
+	Kinds map[string]string `json:"kinds"`
+}
+
+// Set holds the schemas of a kubernetes version along with any schemas added from CRDs
+type Set struct {
+	version     string
+	definitions map[string]*Schema
+	kinds       map[string]*Schema
+}
+
+// Versions returns the kubernetes versions with bundled schemas
+func Versions() []string {
+	entries, _ := bundled.ReadDir("schemas")
+	versions := make([]string, 0, len(entries))
+	for _, e := range entries {
+		versions = append(versions, strings.TrimPrefix(strings.TrimSuffix(e.Name(), ".json.gz"), "v"))
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// Load returns the bundled schemas for a kubernetes version such as 1.31
+func Load(version string) (*Set, error) {
+	version = strings.TrimPrefix(version, "v")
+	f, err := bundled.Open(fmt.Sprintf("schemas/v%s.json.gz", version))
+	if err != nil {
+		return nil, fmt.Errorf("%w %s, available versions are %s", ErrUnknownVersion, version, strings.Join(Versions(), ", "))
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var b Bundle
+	if err := json.NewDecoder(gz).Decode(&b); err != nil {
+		return nil, err
+	}
+
+	s := &Set{
+		version:     version,
+		definitions: b.Definitions,
+		kinds:       make(map[string]*Schema, len(b.Kinds)),
+	}
+	for k, def := range b.Kinds {
+		s.kinds[k] = &Schema{Ref: definitionRefPrefix + def}
+	}
+
+	return s, nil
+}
+
+// Version returns the kubernetes version the set was loaded for
+func (s *Set) Version() string {
+	return s.version
+}
+
+// Clone returns a copy of the set that CRDs can be added to without modifying the original
+func (s *Set) Clone() *Set {
+	return &Set{
+		version:     s.version,
+		definitions: s.definitions,
+		kinds:       maps.Clone(s.kinds),
+	}
+}
+
+// Lookup returns the schema for an apiVersion and kind
+func (s *Set) Lookup(apiVersion, kind string) (*Schema, bool) {
+	schema, ok := s.kinds[apiVersion+"/"+kind]
+	return schema, ok
+}
+
+type crd struct {
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind string `json:"kind"`
+		} `json:"names"`
+		Version    string `json:"version"`
+		Validation *struct {
+			OpenAPIV3Schema *Schema `json:"openAPIV3Schema"`
+		} `json:"validation"`
+		Versions []struct {
+			Name   string `json:"name"`
+			Schema *struct {
+				OpenAPIV3Schema *Schema `json:"openAPIV3Schema"`
+			} `json:"schema"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+// AddCRD registers the schema of every version served by a CustomResourceDefinition
+func (s *Set) AddCRD(obj map[string]any) error {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	var c crd
+	if err := json.Unmarshal(b, &c); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCRD, err)
+	}
+	if c.Spec.Group == "" || c.Spec.Names.Kind == "" {
+		return fmt.Errorf("%w: missing spec.group or spec.names.kind", ErrInvalidCRD)
+	}
+
+	for _, v := range c.Spec.Versions {
+		if v.Schema != nil && v.Schema.OpenAPIV3Schema != nil {
+			s.kinds[c.Spec.Group+"/"+v.Name+"/"+c.Spec.Names.Kind] = withTypeMeta(v.Schema.OpenAPIV3Schema)
+		} else if c.Spec.Validation != nil && c.Spec.Validation.OpenAPIV3Schema != nil {
+			s.kinds[c.Spec.Group+"/"+v.Name+"/"+c.Spec.Names.Kind] = withTypeMeta(c.Spec.Validation.OpenAPIV3Schema)
+		}
+	}
+
+	if len(c.Spec.Versions) == 0 && c.Spec.Version != "" && c.Spec.Validation != nil && c.Spec.Validation.OpenAPIV3Schema != nil {
+		s.kinds[c.Spec.Group+"/"+c.Spec.Version+"/"+c.Spec.Names.Kind] = withTypeMeta(c.Spec.Validation.OpenAPIV3Schema)
+	}
+
+	return nil
+}
+
+// AddCRDs registers every CustomResourceDefinition found in a yaml stream, ignoring other documents
+func (s *Set) AddCRDs(r io.Reader) error {
+	d := yaml.NewDecoder(r)
+	for {
+		var obj map[string]any
+		err := d.Decode(&obj)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if obj["kind"] != "CustomResourceDefinition" {
+			continue
+		}
+		if err := s.AddCRD(obj); err != nil {
+			return err
+		}
+	}
+}
+
+// withTypeMeta adds apiVersion and kind to a CRD schema and validates metadata as ObjectMeta, as the apiserver does
+func withTypeMeta(schema *Schema) *Schema {
+	if len(schema.Properties) == 0 {
+		return schema
+	}
+
+	s := *schema
+	s.Properties = maps.Clone(schema.Properties)
+	for _, key := range []string{"apiVersion", "kind"} {
+		if _, ok := s.Properties[key]; !ok {
+			s.Properties[key] = &Schema{Type: "string"}
+		}
+	}
+	s.Properties["metadata"] = &Schema{Ref: definitionRefPrefix + objectMetaDefinition}
+
+	return &s
+}
+
+func (s *Set) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = s.definitions[strings.TrimPrefix(schema.Ref, definitionRefPrefix)]
+	}
+	return schema
+}
@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoad(t *testing.T) {
+	for _, v := range []string{"1.28", "1.29", "1.30", "v1.31"} {
+		t.Run(v, func(t *testing.T) {
+			s, err := Load(v)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if _, ok := s.Lookup("apps/v1", "Deployment"); !ok {
+				t.Error("expected a schema for apps/v1 Deployment")
+			}
+			if _, ok := s.Lookup("v1", "ConfigMap"); !ok {
+				t.Error("expected a schema for v1 ConfigMap")
+			}
+		})
+	}
+
+	t.Run("unknown version", func(t *testing.T) {
+		if _, err := Load("1.2"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestSet_Validate(t *testing.T) {
+	crds := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  group: example.com
+  names: {kind: Widget}
+  versions:
+    - name: v1
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              required: [size]
+              properties:
+                size: {type: integer}
+                port: {x-kubernetes-int-or-string: true}
+                extra: {type: object, x-kubernetes-preserve-unknown-fields: true}`
+
+	tests := []struct {
+		name     string
+		input    string
+		want     []Violation
+		wantSkip bool
+	}{
+		{
+			name: "valid deployment with quantities and int-or-string",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector: {matchLabels: {app: web}}
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx
+          ports: [{containerPort: 80}]
+          resources: {requests: {cpu: 0.5, memory: 128Mi}, limits: {cpu: 1}}
+          readinessProbe: {httpGet: {port: http}}`,
+		},
+		{
+			name: "wrong type, unknown field and missing required field",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: three
+  selector: {matchLabels: {app: web}}
+  template:
+    spec:
+      containers:
+        - image: nginx
+          color: red`,
+			want: []Violation{
+				{Path: ".spec.replicas", Line: 6, Column: 13, Message: `expected integer, got string "three"`},
+				{Path: ".spec.template.spec.containers[0].color", Line: 12, Column: 11, Message: `unknown field "color"`},
+				{Path: ".spec.template.spec.containers[0]", Line: 11, Column: 11, Message: `missing required field "name"`},
+			},
+		},
+		{
+			name: "custom resource",
+			input: `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+spec:
+  port: http
+  extra: {anything: [1, 2]}
+  color: red`,
+			want: []Violation{
+				{Path: ".spec.color", Line: 8, Column: 3, Message: `unknown field "color"`},
+				{Path: ".spec", Line: 6, Column: 3, Message: `missing required field "size"`},
+			},
+		},
+		{
+			name:     "unknown kind is skipped",
+			input:    "apiVersion: example.com/v1\nkind: Gadget\n",
+			wantSkip: true,
+		},
+	}
+
+	s, err := Load(DefaultVersion)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := s.AddCRDs(strings.NewReader(crds)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.input), &doc); err != nil {
+				t.Fatalf("failed to parse input: %v", err)
+			}
+
+			got, ok := s.Validate(&doc)
+			if ok == tt.wantSkip {
+				t.Errorf("Validate() found schema = %v, want %v", ok, !tt.wantSkip)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
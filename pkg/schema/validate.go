@@ -0,0 +1,198 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Violation is a single schema violation within a document
+type Violation struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e Violation) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks a yaml document against the schema registered for its apiVersion and kind.
+// It returns false when there is no schema for the document.
+func (s *Set) Validate(doc *yaml.Node) ([]Violation, bool) {
+	n := content(doc)
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil, false
+	}
+
+	var apiVersion, kind string
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		switch n.Content[i].Value {
+		case "apiVersion":
+			apiVersion = n.Content[i+1].Value
+		case "kind":
+			kind = n.Content[i+1].Value
+		}
+	}
+
+	schema, ok := s.Lookup(apiVersion, kind)
+	if !ok {
+		return nil, false
+	}
+
+	v := validator{set: s}
+	v.validate(n, schema, "")
+	return v.errs, true
+}
+
+type validator struct {
+	set  *Set
+	errs []Violation
+}
+
+func (v *validator) errorf(n *yaml.Node, path string, format string, args ...any) {
+	if path == "" {
+		path = "."
+	}
+	v.errs = append(v.errs, Violation{
+		Path:    path,
+		Line:    n.Line,
+		Column:  n.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validate(n *yaml.Node, schema *Schema, path string) {
+	schema = v.set.resolve(schema)
+	n = content(n)
+	if schema == nil || n == nil || n.Tag == "!!null" {
+		return
+	}
+
+	for _, s := range schema.AllOf {
+		v.validate(n, s, path)
+	}
+	if len(schema.AnyOf) > 0 && !v.matchesAny(n, schema.AnyOf, path) {
+		v.errorf(n, path, "does not match any of the allowed schemas")
+	}
+	if len(schema.OneOf) > 0 && !v.matchesAny(n, schema.OneOf, path) {
+		v.errorf(n, path, "does not match any of the allowed schemas")
+	}
+
+	switch {
+	case schema.IntOrString || schema.Format == "int-or-string":
+		v.expectScalar(n, path, "integer or string", "!!int", "!!str")
+	case schema.Format == "quantity":
+		v.expectScalar(n, path, "quantity", "!!int", "!!float", "!!str")
+	case schema.Type == "object" || (schema.Type == "" && len(schema.Properties) > 0):
+		v.validateObject(n, schema, path)
+	case schema.Type == "array":
+		if n.Kind != yaml.SequenceNode {
+			v.errorf(n, path, "expected array, got %s", describe(n))
+			return
+		}
+		for i, item := range n.Content {
+			v.validate(item, schema.Items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case schema.Type == "string":
+		v.expectScalar(n, path, "string", "!!str", "!!timestamp", "!!binary")
+	case schema.Type == "integer":
+		v.expectScalar(n, path, "integer", "!!int")
+	case schema.Type == "number":
+		v.expectScalar(n, path, "number", "!!int", "!!float")
+	case schema.Type == "boolean":
+		v.expectScalar(n, path, "boolean", "!!bool")
+	}
+}
+
+func (v *validator) validateObject(n *yaml.Node, schema *Schema, path string) {
+	if n.Kind != yaml.MappingNode {
+		v.errorf(n, path, "expected object, got %s", describe(n))
+		return
+	}
+
+	seen := make(map[string]bool, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		seen[key.Value] = true
+		fieldPath := path + "." + key.Value
+
+		switch prop, ok := schema.Properties[key.Value]; {
+		case ok:
+			v.validate(value, prop, fieldPath)
+		case schema.AdditionalProperties != nil:
+			v.validate(value, schema.AdditionalProperties, fieldPath)
+		case len(schema.Properties) > 0 && !schema.PreserveUnknownFields && !schema.EmbeddedResource:
+			v.errorf(key, fieldPath, "unknown field %q", key.Value)
+		}
+	}
+
+	for _, r := range schema.Required {
+		if !seen[r] {
+			v.errorf(n, path, "missing required field %q", r)
+		}
+	}
+}
+
+func (v *validator) matchesAny(n *yaml.Node, schemas []*Schema, path string) bool {
+	for _, s := range schemas {
+		sub := validator{set: v.set}
+		sub.validate(n, s, path)
+		if len(sub.errs) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) expectScalar(n *yaml.Node, path string, want string, tags ...string) {
+	if n.Kind == yaml.ScalarNode {
+		for _, t := range tags {
+			if n.ShortTag() == t {
+				return
+			}
+		}
+	}
+	v.errorf(n, path, "expected %s, got %s", want, describe(n))
+}
+
+// content unwraps document and alias nodes
+func content(n *yaml.Node) *yaml.Node {
+	for n != nil {
+		switch {
+		case n.Kind == yaml.DocumentNode && len(n.Content) > 0:
+			n = n.Content[0]
+		case n.Kind == yaml.DocumentNode:
+			return nil
+		case n.Kind == yaml.AliasNode:
+			n = n.Alias
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+func describe(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch n.ShortTag() {
+	case "!!str":
+		return fmt.Sprintf("string %q", n.Value)
+	case "!!int":
+		return "integer " + n.Value
+	case "!!float":
+		return "number " + n.Value
+	case "!!bool":
+		return "boolean " + n.Value
+	default:
+		return strings.TrimPrefix(n.ShortTag(), "!!")
+	}
+}
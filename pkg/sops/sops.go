@@ -0,0 +1,456 @@
+// Package sops encrypts and decrypts yaml documents in the layout written by SOPS using age keys, so files
+// encrypted here can be decrypted by sops and tools built on it, and the other way around.
+package sops
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// MetadataKey is the top level key holding the sops metadata of an encrypted document
+	MetadataKey = "sops"
+	// SecretRegex selects the fields of a Kubernetes Secret that hold values
+	SecretRegex = "^(data|stringData)$"
+	// version is the sops release Encrypt writes files for, the one the fixtures in testing were encrypted with
+	version   = "3.9.4"
+	nonceSize = 32
+)
+
+var (
+	ErrNoRecipients   = errors.New("no age recipients")
+	ErrNoIdentities   = errors.New("no age identities")
+	ErrNotEncrypted   = errors.New("document is not encrypted")
+	ErrNoMatchingKey  = errors.New("no identity can decrypt the data key")
+	ErrMACMismatch    = errors.New("message authentication code mismatch")
+	ErrInvalidValue   = errors.New("invalid encrypted value")
+	encryptedValueExp = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+)
+
+// Metadata is the sops entry of an encrypted document
+type Metadata struct {
+	Age            []AgeKey `yaml:"age"`
+	LastModified   string   `yaml:"lastmodified"`
+	MAC            string   `yaml:"mac"`
+	EncryptedRegex string   `yaml:"encrypted_regex,omitempty"`
+	Version        string   `yaml:"version"`
+}
+
+// AgeKey is the data key encrypted to a single age recipient
+type AgeKey struct {
+	Recipient string `yaml:"recipient"`
+	Enc       string `yaml:"enc"`
+}
+
+// Encrypter encrypts the values of the fields matching a regex to a set of age recipients
+type Encrypter struct {
+	recipients []*age.X25519Recipient
+	regex      *regexp.Regexp
+	now        func() time.Time
+}
+
+// ReadRecipients reads an age recipients file, one public key per line with # comments, and returns an
+// Encrypter for the fields matching regex
+func ReadRecipients(r io.Reader, regex string) (*Encrypter, error) {
+	exp, err := regexp.Compile(regex)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Encrypter{regex: exp, now: time.Now}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		recipient, err := age.ParseX25519Recipient(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		e.recipients = append(e.recipients, recipient)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(e.recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	return e, nil
+}
+
+// Encrypt encrypts the document in place and adds the sops metadata. Documents that already carry sops
+// metadata are left untouched.
+func (e *Encrypter) Encrypt(doc *yaml.Node) error {
+	root := mapping(doc)
+	if root == nil || value(root, MetadataKey) != nil {
+		return nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	hash := sha512.New()
+	err := walk(root, nil, func(n *yaml.Node, path []string) error {
+		hash.Write(macBytes(n))
+		if !e.matches(path) {
+			return nil
+		}
+		return encryptNode(n, key, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	md := Metadata{
+		LastModified:   e.now().UTC().Format(time.RFC3339),
+		EncryptedRegex: e.regex.String(),
+		Version:        version,
+	}
+
+	md.MAC, err = encrypt(fmt.Sprintf("%X", hash.Sum(nil)), "str", key, md.LastModified)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range e.recipients {
+		enc, err := wrapKey(key, r)
+		if err != nil {
+			return err
+		}
+		md.Age = append(md.Age, AgeKey{Recipient: r.String(), Enc: enc})
+	}
+
+	var mdNode yaml.Node
+	if err := mdNode.Encode(md); err != nil {
+		return err
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: MetadataKey}, &mdNode)
+
+	return nil
+}
+
+func (e *Encrypter) matches(path []string) bool {
+	for _, p := range path {
+		if e.regex.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decrypter decrypts documents whose data key was encrypted to one of its age identities
+type Decrypter struct {
+	identities []age.Identity
+}
+
+// ReadIdentities reads an age identity file as written by age-keygen
+func ReadIdentities(r io.Reader) (*Decrypter, error) {
+	ids, err := age.ParseIdentities(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrNoIdentities
+	}
+
+	return &Decrypter{identities: ids}, nil
+}
+
+// IsEncrypted reports whether the document carries sops metadata
+func IsEncrypted(doc *yaml.Node) bool {
+	root := mapping(doc)
+	return root != nil && value(root, MetadataKey) != nil
+}
+
+// Decrypt decrypts the document in place, verifies its message authentication code and removes the sops metadata
+func (d *Decrypter) Decrypt(doc *yaml.Node) error {
+	root := mapping(doc)
+	if root == nil {
+		return ErrNotEncrypted
+	}
+
+	mdNode := value(root, MetadataKey)
+	if mdNode == nil {
+		return ErrNotEncrypted
+	}
+
+	var md Metadata
+	if err := mdNode.Decode(&md); err != nil {
+		return fmt.Errorf("reading sops metadata: %w", err)
+	}
+
+	key, err := d.unwrapKey(md.Age)
+	if err != nil {
+		return err
+	}
+
+	hash := sha512.New()
+	err = walk(root, nil, func(n *yaml.Node, path []string) error {
+		if err := decryptNode(n, key, path); err != nil {
+			return err
+		}
+		hash.Write(macBytes(n))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	mac, _, err := decrypt(md.MAC, key, md.LastModified)
+	if err != nil {
+		return fmt.Errorf("decrypting mac: %w", err)
+	}
+	if mac != fmt.Sprintf("%X", hash.Sum(nil)) {
+		return ErrMACMismatch
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		if root.Content[i].Value == MetadataKey {
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (d *Decrypter) unwrapKey(keys []AgeKey) ([]byte, error) {
+	for _, k := range keys {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(k.Enc)), d.identities...)
+		if err != nil {
+			continue
+		}
+		return io.ReadAll(r)
+	}
+	return nil, ErrNoMatchingKey
+}
+
+func wrapKey(key []byte, recipient age.Recipient) (string, error) {
+	var buf bytes.Buffer
+	a := armor.NewWriter(&buf)
+	w, err := age.Encrypt(a, recipient)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(key); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	if err := a.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// walk calls fn for every scalar of the tree with the mapping keys leading to it, in document order as sops
+// does. The sops metadata is skipped.
+func walk(n *yaml.Node, path []string, fn func(n *yaml.Node, path []string) error) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i].Value
+			if len(path) == 0 && k == MetadataKey {
+				continue
+			}
+			if err := walk(n.Content[i+1], append(path[:len(path):len(path)], k), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if err := walk(item, path, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(n, path)
+	}
+	return nil
+}
+
+// macBytes returns the bytes sops hashes for a plaintext value
+func macBytes(n *yaml.Node) []byte {
+	switch n.ShortTag() {
+	case "!!null":
+		return nil
+	case "!!bool":
+		if b, _ := strconv.ParseBool(n.Value); b {
+			return []byte("True")
+		}
+		return []byte("False")
+	case "!!float":
+		if f, err := strconv.ParseFloat(n.Value, 64); err == nil {
+			return []byte(strconv.FormatFloat(f, 'f', -1, 64))
+		}
+	}
+	return []byte(n.Value)
+}
+
+func encryptNode(n *yaml.Node, key []byte, path []string) error {
+	typ := "str"
+	switch n.ShortTag() {
+	case "!!null":
+		return nil
+	case "!!int":
+		typ = "int"
+	case "!!float":
+		typ = "float"
+	case "!!bool":
+		typ = "bool"
+	}
+
+	// sops leaves empty strings as they are
+	if typ == "str" && n.Value == "" {
+		return nil
+	}
+
+	plain := n.Value
+	// sops writes booleans the way python does
+	if typ == "bool" {
+		plain = string(macBytes(n))
+	}
+
+	enc, err := encrypt(plain, typ, key, additionalData(path))
+	if err != nil {
+		return err
+	}
+
+	n.Value, n.Tag, n.Style = enc, "!!str", 0
+	return nil
+}
+
+func decryptNode(n *yaml.Node, key []byte, path []string) error {
+	if !strings.HasPrefix(n.Value, "ENC[") {
+		return nil
+	}
+
+	plain, typ, err := decrypt(n.Value, key, additionalData(path))
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+	}
+
+	n.Value, n.Style = plain, 0
+	switch typ {
+	case "int":
+		n.Tag = "!!int"
+	case "float":
+		n.Tag = "!!float"
+	case "bool":
+		b, err := strconv.ParseBool(plain)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(path, "."), ErrInvalidValue)
+		}
+		n.Value, n.Tag = strconv.FormatBool(b), "!!bool"
+	default:
+		n.Tag = "!!str"
+	}
+	return nil
+}
+
+func additionalData(path []string) string {
+	return strings.Join(path, ":") + ":"
+}
+
+func encrypt(plain, typ string, key []byte, aad string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, nonceSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	out := gcm.Seal(nil, iv, []byte(plain), []byte(aad))
+	data, tag := out[:len(out)-gcm.Overhead()], out[len(out)-gcm.Overhead():]
+	enc := base64.StdEncoding.EncodeToString
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", enc(data), enc(iv), enc(tag), typ), nil
+}
+
+func decrypt(value string, key []byte, aad string) (string, string, error) {
+	m := encryptedValueExp.FindStringSubmatch(value)
+	if m == nil {
+		return "", "", ErrInvalidValue
+	}
+
+	parts := make([][]byte, 0, 3)
+	for _, s := range m[1:4] {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %w", ErrInvalidValue, err)
+		}
+		parts = append(parts, b)
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", "", err
+	}
+	if len(iv) != nonceSize {
+		return "", "", ErrInvalidValue
+	}
+
+	plain, err := gcm.Open(nil, iv, append(data, tag...), []byte(aad))
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(plain), m[4], nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, nonceSize)
+}
+
+func mapping(doc *yaml.Node) *yaml.Node {
+	if doc == nil {
+		return nil
+	}
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil
+	}
+	return doc
+}
+
+func value(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package sops
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"gopkg.in/yaml.v3"
+)
+
+const secret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+data:
+  password: aHVudGVyMg==
+stringData:
+  user: admin
+  port: 5432
+  empty: ""
+`
+
+func newKeys(t *testing.T) (*Encrypter, *Decrypter) {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := ReadRecipients(strings.NewReader("# team key\n"+id.Recipient().String()+"\n"), SecretRegex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	d, err := ReadIdentities(strings.NewReader(id.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, d
+}
+
+func parse(t *testing.T, s string) *yaml.Node {
+	t.Helper()
+	var n yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &n); err != nil {
+		t.Fatal(err)
+	}
+	return &n
+}
+
+func encode(t *testing.T, n *yaml.Node) string {
+	t.Helper()
+	b, err := yaml.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	e, d := newKeys(t)
+	doc := parse(t, secret)
+
+	if err := e.Encrypt(doc); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !IsEncrypted(doc) {
+		t.Fatal("expected the document to carry sops metadata")
+	}
+
+	encrypted := encode(t, doc)
+	for _, plain := range []string{"aHVudGVyMg==", "admin", "5432"} {
+		if strings.Contains(encrypted, plain) {
+			t.Errorf("expected %q to be encrypted:\n%s", plain, encrypted)
+		}
+	}
+	for _, want := range []string{"name: creds", "type:int]", `empty: ""`, "lastmodified: \"2024-01-02T03:04:05Z\"", "encrypted_regex: ^(data|stringData)$"} {
+		if !strings.Contains(encrypted, want) {
+			t.Errorf("expected %q in:\n%s", want, encrypted)
+		}
+	}
+
+	roundTrip := parse(t, encrypted)
+	if err := d.Decrypt(roundTrip); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := encode(t, roundTrip); got != encode(t, parse(t, secret)) {
+		t.Errorf("Decrypt() =\n%s\nwant\n%s", got, secret)
+	}
+}
+
+// readFixture parses a file of the testing directory, which holds files encrypted by sops itself
+func readFixture(t *testing.T, name string) *yaml.Node {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testing", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parse(t, string(b))
+}
+
+func fixtureDecrypter(t *testing.T) *Decrypter {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testing", "age.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	d, err := ReadIdentities(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDecrypt_sopsFiles(t *testing.T) {
+	d := fixtureDecrypter(t)
+	want := encode(t, readFixture(t, "secret.yaml"))
+
+	// secret.sops.yaml was encrypted with --encrypted-regex, all.sops.yaml without, so every value is encrypted
+	for _, name := range []string{"secret.sops.yaml", "all.sops.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			doc := readFixture(t, name)
+			if err := d.Decrypt(doc); err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if got := encode(t, doc); got != want {
+				t.Errorf("Decrypt() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+
+	t.Run("tampered value", func(t *testing.T) {
+		doc := readFixture(t, "secret.sops.yaml")
+		data := value(mapping(doc), "stringData")
+		value(data, "empty").Value = "changed"
+		if err := d.Decrypt(doc); !errors.Is(err, ErrMACMismatch) {
+			t.Errorf("Decrypt() error = %v, want %v", err, ErrMACMismatch)
+		}
+	})
+}
+
+func TestEncrypt_version(t *testing.T) {
+	e, _ := newKeys(t)
+	doc := parse(t, secret)
+	if err := e.Encrypt(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var got, fixture Metadata
+	if err := value(mapping(doc), MetadataKey).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if err := value(mapping(readFixture(t, "secret.sops.yaml")), MetadataKey).Decode(&fixture); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != fixture.Version {
+		t.Errorf("version = %q, want %q, the version of sops the fixtures were encrypted with", got.Version, fixture.Version)
+	}
+}
+
+// TestEncrypt_sopsDecrypts checks sops itself accepts the output of Encrypt, when it is installed
+func TestEncrypt_sopsDecrypts(t *testing.T) {
+	if _, err := exec.LookPath("sops"); err != nil {
+		t.Skip("sops is not installed")
+	}
+
+	b, err := os.ReadFile(filepath.Join("testing", "age.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := ""
+	for _, line := range strings.Split(string(b), "\n") {
+		if r, ok := strings.CutPrefix(line, "# public key: "); ok {
+			recipient = r
+		}
+	}
+	e, err := ReadRecipients(strings.NewReader(recipient), SecretRegex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := readFixture(t, "secret.yaml")
+	if err := e.Encrypt(doc); err != nil {
+		t.Fatal(err)
+	}
+	encrypted := filepath.Join(t.TempDir(), "secret.yaml")
+	if err := os.WriteFile(encrypted, []byte(encode(t, doc)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile, err := filepath.Abs(filepath.Join("testing", "age.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("sops", "--disable-version-check", "decrypt", encrypted)
+	cmd.Env = append(os.Environ(), "SOPS_AGE_KEY_FILE="+keyFile)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("sops decrypt: %v", err)
+	}
+	if got, want := encode(t, parse(t, string(out))), encode(t, readFixture(t, "secret.yaml")); got != want {
+		t.Errorf("sops decrypt =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEncrypt_alreadyEncrypted(t *testing.T) {
+	e, _ := newKeys(t)
+	doc := parse(t, secret)
+	if err := e.Encrypt(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	once := encode(t, doc)
+	if err := e.Encrypt(doc); err != nil {
+		t.Fatal(err)
+	}
+	if twice := encode(t, doc); twice != once {
+		t.Errorf("expected an encrypted document to be left untouched")
+	}
+}
+
+func TestDecrypt_errors(t *testing.T) {
+	e, d := newKeys(t)
+	_, other := newKeys(t)
+
+	encrypt := func() *yaml.Node {
+		doc := parse(t, secret)
+		if err := e.Encrypt(doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+
+	tests := []struct {
+		name      string
+		doc       func() *yaml.Node
+		decrypter *Decrypter
+		wantErr   error
+	}{
+		{
+			name:      "not encrypted",
+			doc:       func() *yaml.Node { return parse(t, secret) },
+			decrypter: d,
+			wantErr:   ErrNotEncrypted,
+		},
+		{
+			name:      "wrong identity",
+			doc:       encrypt,
+			decrypter: other,
+			wantErr:   ErrNoMatchingKey,
+		},
+		{
+			name: "tampered plaintext",
+			doc: func() *yaml.Node {
+				return parse(t, strings.Replace(encode(t, encrypt()), "name: creds", "name: other", 1))
+			},
+			decrypter: d,
+			wantErr:   ErrMACMismatch,
+		},
+		{
+			name: "invalid value",
+			doc: func() *yaml.Node {
+				s := encode(t, encrypt())
+				i := strings.Index(s, "password: ENC[")
+				return parse(t, s[:i]+"password: ENC[AES256_GCM,broken]"+s[strings.Index(s[i:], "\n")+i:])
+			},
+			decrypter: d,
+			wantErr:   ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.decrypter.Decrypt(tt.doc()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadRecipients(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "empty", input: "# nothing here\n\n", wantErr: true},
+		{name: "invalid key", input: "age1notakey\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadRecipients(strings.NewReader(tt.input), SecretRegex); (err != nil) != tt.wantErr {
+				t.Errorf("ReadRecipients() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}